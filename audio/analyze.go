@@ -0,0 +1,204 @@
+package audio
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Features are measured from each block of samples. Levels are 0-1, scaled
+// to the loudest recent sound so quiet and loud rooms both fill the range.
+type Features struct {
+	RMS    float64   // Loudness of the raw signal, not scaled
+	Level  float64   // Loudness
+	Bands  []float64 // Energy of each frequency band, lowest first
+	Bass   float64   // Energy below 250Hz
+	Mid    float64   // Energy from 250Hz to 2kHz
+	Treble float64   // Energy above 2kHz
+	Onset  float64   // Rise in energy across the spectrum since the last block
+	Beat   bool      // The bass jumped well above its recent average
+}
+
+const (
+	minFreq      = 40
+	maxFreq      = 16000
+	bassTop      = 250
+	midTop       = 2000
+	beatRatio    = 1.5  // Bass energy over its average that counts as a beat
+	beatGap      = 0.25 // Seconds between beats at most 240 BPM
+	beatHistory  = 1.0  // Seconds of bass energy averaged for beats
+	peakHalfLife = 4.0  // Seconds for the level scaling to halve after a peak
+	silence      = 1e-4 // Energy treated as no sound at all
+)
+
+// Analyzer measures features over a sliding window of samples
+type Analyzer struct {
+	rate      int
+	hop       int // Samples between measurements
+	window    []float64
+	hann      []float64
+	spec      []complex128
+	mags      []float64
+	prev      []float64 // Magnitudes of the last block, for onsets
+	edges     []int     // FFT bins where each band starts, with the end last
+	history   []float64 // Recent bass energy
+	sinceHit  float64   // Seconds since the last beat
+	decay     float64   // Peak fall per hop
+	peaks     peaks
+	bandPeaks []float64
+}
+
+// peaks are the recent loudest values, used to scale features to 0-1
+type peaks struct {
+	level, onset, bass, mid, treble float64
+}
+
+// NewAnalyzer measures rate Hz audio with an FFT of size samples, a power of
+// two, every hop samples, in bands log spaced frequency bands
+func NewAnalyzer(rate, size, hop, bands int) (*Analyzer, error) {
+	if size < 2 || size&(size-1) != 0 {
+		return nil, fmt.Errorf("FFT size %v is not a power of two", size)
+	}
+	if hop < 1 || hop > size {
+		return nil, fmt.Errorf("hop %v is outside 1-%v, the FFT size", hop, size)
+	}
+	if rate < 1 || bands < 1 {
+		return nil, errors.New("rate and bands must be at least 1")
+	}
+	a := &Analyzer{
+		rate:      rate,
+		hop:       hop,
+		window:    make([]float64, size),
+		hann:      make([]float64, size),
+		spec:      make([]complex128, size),
+		mags:      make([]float64, size/2),
+		prev:      make([]float64, size/2),
+		bandPeaks: make([]float64, bands),
+	}
+	for iS := range a.hann {
+		a.hann[iS] = 0.5 - 0.5*math.Cos(2*math.Pi*float64(iS)/float64(size-1))
+	}
+	top := math.Min(maxFreq, float64(rate)/2)
+	for iB := 0; iB <= bands; iB++ {
+		f := minFreq * math.Pow(top/minFreq, float64(iB)/float64(bands))
+		a.edges = append(a.edges, a.bin(f))
+	}
+	a.decay = math.Pow(0.5, a.hopSeconds()/peakHalfLife)
+	a.history = make([]float64, 0, int(beatHistory/a.hopSeconds())+1)
+	a.sinceHit = beatGap
+	return a, nil
+}
+
+// Hop is the number of samples Add takes for each measurement
+func (a *Analyzer) Hop() int {
+	return a.hop
+}
+
+func (a *Analyzer) hopSeconds() float64 {
+	return float64(a.hop) / float64(a.rate)
+}
+
+// bin returns the FFT bin holding frequency f
+func (a *Analyzer) bin(f float64) int {
+	b := int(f * float64(len(a.window)) / float64(a.rate))
+	if b < 1 {
+		b = 1
+	}
+	if b > len(a.mags) {
+		b = len(a.mags)
+	}
+	return b
+}
+
+// Add takes the next hop samples and measures the window ending with them.
+// Only the last FFT size samples of a longer block are used.
+func (a *Analyzer) Add(samples []float64) Features {
+	if len(samples) > len(a.window) {
+		samples = samples[len(samples)-len(a.window):]
+	}
+	// slide the window along
+	copy(a.window, a.window[len(samples):])
+	copy(a.window[len(a.window)-len(samples):], samples)
+
+	f := Features{}
+	sum := 0.0
+	for _, s := range samples {
+		sum += s * s
+	}
+	f.RMS = math.Sqrt(sum / float64(len(samples)))
+	f.Level = a.scale(&a.peaks.level, f.RMS)
+
+	for iS, s := range a.window {
+		a.spec[iS] = complex(s*a.hann[iS], 0)
+	}
+	fft(a.spec)
+	flux := 0.0
+	for iM := range a.mags {
+		re, im := real(a.spec[iM]), imag(a.spec[iM])
+		a.mags[iM] = math.Sqrt(re*re+im*im) / float64(len(a.window))
+		if d := a.mags[iM] - a.prev[iM]; d > 0 {
+			flux += d
+		}
+	}
+	copy(a.prev, a.mags)
+	f.Onset = a.scale(&a.peaks.onset, flux)
+
+	bass := a.energy(a.bin(minFreq), a.bin(bassTop))
+	f.Bass = a.scale(&a.peaks.bass, bass)
+	f.Mid = a.scale(&a.peaks.mid, a.energy(a.bin(bassTop), a.bin(midTop)))
+	f.Treble = a.scale(&a.peaks.treble, a.energy(a.bin(midTop), a.bin(maxFreq)))
+	f.Bands = make([]float64, len(a.bandPeaks))
+	for iB := range f.Bands {
+		f.Bands[iB] = a.scale(&a.bandPeaks[iB], a.energy(a.edges[iB], a.edges[iB+1]))
+	}
+
+	f.Beat = a.beat(bass)
+	return f
+}
+
+// energy is the mean magnitude of bins from up to but not including to
+func (a *Analyzer) energy(from, to int) float64 {
+	if to <= from {
+		to = from + 1
+	}
+	if to > len(a.mags) {
+		to = len(a.mags)
+	}
+	sum := 0.0
+	for _, m := range a.mags[from:to] {
+		sum += m
+	}
+	return sum / float64(to-from)
+}
+
+// scale returns v over its recent peak, and updates the peak
+func (a *Analyzer) scale(peak *float64, v float64) float64 {
+	*peak = math.Max(v, *peak*a.decay)
+	if *peak < silence {
+		return 0
+	}
+	return v / *peak
+}
+
+// beat reports a beat when bass jumps above its recent average
+func (a *Analyzer) beat(bass float64) bool {
+	avg := 0.0
+	for _, b := range a.history {
+		avg += b
+	}
+	full := len(a.history) == cap(a.history)
+	if len(a.history) > 0 {
+		avg /= float64(len(a.history))
+	}
+	if full {
+		a.history = append(a.history[:0], a.history[1:]...)
+	}
+	a.history = append(a.history, bass)
+
+	a.sinceHit += a.hopSeconds()
+	if !full || bass < silence || bass < avg*beatRatio || a.sinceHit < beatGap {
+		return false
+	}
+	a.sinceHit = 0
+	return true
+}
@@ -0,0 +1,123 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"math/cmplx"
+	"testing"
+)
+
+func TestFFT(t *testing.T) {
+	// a cosine at bin 3 of 16
+	x := make([]complex128, 16)
+	for iX := range x {
+		x[iX] = complex(math.Cos(2*math.Pi*3*float64(iX)/16), 0)
+	}
+	fft(x)
+	for iX, v := range x {
+		want := 0.0
+		if iX == 3 || iX == 13 {
+			want = 8
+		}
+		if math.Abs(cmplx.Abs(v)-want) > 1e-9 {
+			t.Errorf("Bin %v is %v, expected %v", iX, cmplx.Abs(v), want)
+		}
+	}
+}
+
+func TestOpenWAV(t *testing.T) {
+	var b bytes.Buffer
+	samples := []int16{16384, -16384, 32767, 32767}
+	b.WriteString("RIFF")
+	binary.Write(&b, binary.LittleEndian, uint32(36+len(samples)*2))
+	b.WriteString("WAVEfmt ")
+	binary.Write(&b, binary.LittleEndian, []uint32{16})
+	binary.Write(&b, binary.LittleEndian, []uint16{1, 2})
+	binary.Write(&b, binary.LittleEndian, []uint32{8000, 8000 * 4})
+	binary.Write(&b, binary.LittleEndian, []uint16{4, 16})
+	b.WriteString("data")
+	binary.Write(&b, binary.LittleEndian, uint32(len(samples)*2))
+	binary.Write(&b, binary.LittleEndian, samples)
+
+	s, err := OpenWAV(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Rate != 8000 || s.Channels != 2 {
+		t.Errorf("Read %v Hz %v channels, expected 8000 Hz 2 channels", s.Rate, s.Channels)
+	}
+	buf := make([]float64, 4)
+	n, err := s.Read(buf)
+	if err != nil || n != 2 {
+		t.Fatalf("Read %v samples, %v, expected 2", n, err)
+	}
+	// the channels are mixed to mono
+	if buf[0] != 0 || math.Abs(buf[1]-1) > 1e-3 {
+		t.Errorf("Samples %v, expected [0 1]", buf[:2])
+	}
+	if _, err = s.Read(buf); err != io.EOF {
+		t.Errorf("Read after the data returned %v, expected EOF", err)
+	}
+}
+
+func TestBeat(t *testing.T) {
+	const rate, hop = 8000, 256
+	a, err := NewAnalyzer(rate, 1024, hop, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block := make([]float64, hop)
+	beats := 0
+	// a quiet hum with a loud 60Hz kick every half second
+	for iH := 0; iH < rate*4/hop; iH++ {
+		kick := (iH*hop)%(rate/2) < hop*2
+		for iS := range block {
+			tt := float64(iH*hop+iS) / rate
+			block[iS] = 0.01 * math.Sin(2*math.Pi*1000*tt)
+			if kick {
+				block[iS] += 0.8 * math.Sin(2*math.Pi*60*tt)
+			}
+		}
+		f := a.Add(block)
+		if f.Beat {
+			beats++
+		}
+		if f.Level < 0 || f.Level > 1 || f.Bass < 0 || f.Bass > 1 {
+			t.Fatalf("Levels out of range: %+v", f)
+		}
+	}
+	// the first second fills the history
+	if beats < 5 || beats > 7 {
+		t.Errorf("Found %v beats, expected 6", beats)
+	}
+}
+
+func TestNewAnalyzer(t *testing.T) {
+	for _, c := range []struct {
+		size, hop int
+	}{
+		{1000, 256},
+		{0, 256},
+		{1024, 0},
+		{1024, 2048},
+	} {
+		if _, err := NewAnalyzer(8000, c.size, c.hop, 8); err == nil {
+			t.Errorf("Expected an error for size %v and hop %v", c.size, c.hop)
+		}
+	}
+
+	// a block longer than the window keeps its end
+	a, err := NewAnalyzer(8000, 64, 64, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block := make([]float64, 100)
+	for iS := 36; iS < len(block); iS++ {
+		block[iS] = 1
+	}
+	if f := a.Add(block); f.RMS != 1 {
+		t.Errorf("RMS of the last 64 samples %v, expected 1", f.RMS)
+	}
+}
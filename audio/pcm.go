@@ -0,0 +1,96 @@
+// Package audio reads PCM audio and measures the features lights react to:
+// loudness, frequency bands and beats.
+package audio
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Stream reads signed 16 bit little endian PCM, mixing the channels to mono
+type Stream struct {
+	r        io.Reader
+	Rate     int // Samples per second
+	Channels int
+	buf      []byte
+}
+
+// NewRaw reads headerless PCM, as from arecord -f S16_LE or ffmpeg -f s16le
+func NewRaw(r io.Reader, rate, channels int) *Stream {
+	return &Stream{r: r, Rate: rate, Channels: channels}
+}
+
+// OpenWAV reads the header of a 16 bit PCM WAV file and returns a stream of
+// its samples
+func OpenWAV(r io.Reader) (*Stream, error) {
+	var riff [12]byte
+	if _, err := io.ReadFull(r, riff[:]); err != nil {
+		return nil, err
+	}
+	if string(riff[:4]) != "RIFF" || string(riff[8:]) != "WAVE" {
+		return nil, errors.New("not a WAV file")
+	}
+	s := &Stream{r: r}
+	for {
+		var head [8]byte
+		if _, err := io.ReadFull(r, head[:]); err != nil {
+			return nil, fmt.Errorf("no data chunk: %v", err)
+		}
+		id, size := string(head[:4]), int64(binary.LittleEndian.Uint32(head[4:]))
+		switch id {
+		case "fmt ":
+			chunk := make([]byte, size+size%2)
+			if _, err := io.ReadFull(r, chunk); err != nil {
+				return nil, err
+			}
+			if len(chunk) < 16 {
+				return nil, errors.New("short fmt chunk")
+			}
+			format := binary.LittleEndian.Uint16(chunk)
+			bits := binary.LittleEndian.Uint16(chunk[14:])
+			// 0xfffe is WAVE_FORMAT_EXTENSIBLE, PCM in the encoders we have seen
+			if (format != 1 && format != 0xfffe) || bits != 16 {
+				return nil, fmt.Errorf("only 16 bit PCM is supported, not format %v with %v bits", format, bits)
+			}
+			s.Channels = int(binary.LittleEndian.Uint16(chunk[2:]))
+			s.Rate = int(binary.LittleEndian.Uint32(chunk[4:]))
+		case "data":
+			if s.Rate == 0 {
+				return nil, errors.New("data before fmt chunk")
+			}
+			s.r = io.LimitReader(r, size)
+			return s, nil
+		default:
+			if _, err := io.CopyN(io.Discard, r, size+size%2); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// Read fills samples with mono samples from -1 to 1. It returns io.EOF when
+// the stream ends.
+func (s *Stream) Read(samples []float64) (int, error) {
+	frame := 2 * s.Channels
+	if len(s.buf) < len(samples)*frame {
+		s.buf = make([]byte, len(samples)*frame)
+	}
+	n, err := io.ReadFull(s.r, s.buf[:len(samples)*frame])
+	if err == io.ErrUnexpectedEOF {
+		err = nil
+	}
+	n /= frame
+	for iS := 0; iS < n; iS++ {
+		sum := 0.0
+		for iC := 0; iC < s.Channels; iC++ {
+			sum += float64(int16(binary.LittleEndian.Uint16(s.buf[(iS*s.Channels+iC)*2:])))
+		}
+		samples[iS] = sum / float64(s.Channels) / 32768
+	}
+	if n == 0 && err == nil {
+		err = io.EOF
+	}
+	return n, err
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"log"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/tgreiser/cymapper/warp"
+	"gocv.io/x/gocv"
+)
+
+var calibPath = flag.String("file", "calibration.json", "Filename for the calibration output")
+var deviceID = flag.Int("device-id", 0, "Device ID of your webcam")
+var cols = flag.Int("cols", 9, "Inner corners per checkerboard row")
+var rows = flag.Int("rows", 6, "Inner corners per checkerboard column")
+var square = flag.Float64("square", 1, "Size of a checkerboard square, in any unit")
+var frames = flag.Int("frames", 15, "Number of checkerboard views to capture")
+var delayMs = flag.Int("delay-ms", 1000, "Minimum number of milliseconds between captured views")
+var pose = flag.Bool("pose", false, "Add the camera position to an existing calibration -file, from one view of a checkerboard that stays still for every camera")
+
+// color for the progress text
+var green = color.RGBA{0, 255, 0, 0}
+
+func main() {
+	flag.Parse()
+	pattern := image.Point{X: *cols, Y: *rows}
+
+	// open webcam
+	webcam, err := gocv.VideoCaptureDevice(int(*deviceID))
+	if err != nil {
+		fmt.Printf("error opening video capture device: %v\n", deviceID)
+		return
+	}
+	defer webcam.Close()
+
+	// open display window
+	window := gocv.NewWindow("CyMapper Calibrate")
+	defer window.Close()
+
+	// prepare image matricies
+	img := gocv.NewMat()
+	defer img.Close()
+	gray := gocv.NewMat()
+	defer gray.Close()
+
+	// checkerboard corners in board coordinates, the same for every view
+	board := []gocv.Point3f{}
+	for iY := 0; iY < *rows; iY++ {
+		for iX := 0; iX < *cols; iX++ {
+			board = append(board, gocv.Point3f{X: float32(float64(iX) * *square), Y: float32(float64(iY) * *square)})
+		}
+	}
+	if *pose {
+		*frames = 1
+	}
+	objectPoints := gocv.NewPoints3fVector()
+	defer objectPoints.Close()
+	imagePoints := gocv.NewPoints2fVector()
+	defer imagePoints.Close()
+
+	// channel to receive os signal
+	cs := make(chan os.Signal, 1)
+	signal.Notify(cs, os.Interrupt)
+
+	fmt.Printf("Show a %v x %v checkerboard to camera %v, moving it between views\n", *cols, *rows, *deviceID)
+	last := time.Now()
+	captured := 0
+	var size image.Point
+	for captured < *frames {
+		select {
+		case _ = <-cs:
+			fmt.Println("Cancelled")
+			return
+		default:
+		}
+		if ok := webcam.Read(&img); !ok {
+			fmt.Printf("cannot read device %d\n", *deviceID)
+			return
+		}
+		if img.Empty() {
+			continue
+		}
+		size = image.Point{X: img.Cols(), Y: img.Rows()}
+
+		gocv.CvtColor(img, &gray, gocv.ColorBGRToGray)
+		corners := gocv.NewMat()
+		found := gocv.FindChessboardCorners(gray, pattern, &corners, gocv.CalibCBAdaptiveThresh|gocv.CalibCBNormalizeImage)
+		if found && time.Since(last) > time.Duration(*delayMs)*time.Millisecond {
+			gocv.CornerSubPix(gray, &corners, image.Point{X: 11, Y: 11}, image.Point{X: -1, Y: -1},
+				gocv.NewTermCriteria(gocv.EPS|gocv.MaxIter, 30, 0.001))
+			view := gocv.NewPoint3fVectorFromPoints(board)
+			objectPoints.Append(view)
+			view.Close()
+			cv := gocv.NewPoint2fVectorFromMat(corners)
+			imagePoints.Append(cv)
+			cv.Close()
+			captured++
+			last = time.Now()
+			fmt.Printf("Captured view %v of %v\n", captured, *frames)
+		}
+		gocv.DrawChessboardCorners(&img, pattern, corners, found)
+		corners.Close()
+
+		gocv.PutText(&img, fmt.Sprintf("%v / %v", captured, *frames), image.Point{X: 10, Y: 30},
+			gocv.FontHersheyPlain, 2, green, 2)
+		window.IMShow(img)
+		window.WaitKey(1)
+	}
+
+	if *pose {
+		savePose(board, imagePoints.At(0).ToPoints())
+		return
+	}
+
+	fmt.Printf("Calibrating from %v views at %v x %v\n", captured, size.X, size.Y)
+	cameraMatrix := gocv.NewMat()
+	defer cameraMatrix.Close()
+	distCoeffs := gocv.NewMat()
+	defer distCoeffs.Close()
+	rvecs := gocv.NewMat()
+	defer rvecs.Close()
+	tvecs := gocv.NewMat()
+	defer tvecs.Close()
+	rms := gocv.CalibrateCamera(objectPoints, imagePoints, size, &cameraMatrix, &distCoeffs, &rvecs, &tvecs, 0)
+
+	lens := &warp.Lens{
+		Width:  size.X,
+		Height: size.Y,
+		FX:     cameraMatrix.GetDoubleAt(0, 0),
+		FY:     cameraMatrix.GetDoubleAt(1, 1),
+		CX:     cameraMatrix.GetDoubleAt(0, 2),
+		CY:     cameraMatrix.GetDoubleAt(1, 2),
+		K1:     distCoeffs.GetDoubleAt(0, 0),
+		K2:     distCoeffs.GetDoubleAt(0, 1),
+		P1:     distCoeffs.GetDoubleAt(0, 2),
+		P2:     distCoeffs.GetDoubleAt(0, 3),
+		K3:     distCoeffs.GetDoubleAt(0, 4),
+		Error:  rms,
+	}
+	if err := lens.Save(*calibPath); err != nil {
+		log.Fatalf("Unable to write %v: %v\n", *calibPath, err)
+	}
+	fmt.Printf("RMS reprojection error %v px, writing %v\n", rms, *calibPath)
+}
+
+// savePose works out where the camera is relative to the checkerboard and
+// stores it in the calibration file. Cameras posed against the same board
+// share a world frame, with the board on the z=0 plane.
+func savePose(board []gocv.Point3f, corners []gocv.Point2f) {
+	lens, err := warp.LoadLens(*calibPath)
+	if err != nil {
+		log.Fatalf("Calibrate the lens before the pose, unable to load %v: %v\n", *calibPath, err)
+	}
+	src := make([]warp.Point, len(board))
+	dst := make([]warp.Point, len(corners))
+	for iP := range corners {
+		src[iP] = warp.Point{X: float64(board[iP].X), Y: float64(board[iP].Y)}
+		px := warp.Point{X: float64(corners[iP].X), Y: float64(corners[iP].Y)}
+		dst[iP] = lens.Normalize(lens.Undistort(px))
+	}
+	h, err := warp.NewHomography(src, dst)
+	if err != nil {
+		log.Fatalf("Unable to find the checkerboard plane: %v\n", err)
+	}
+	p, err := warp.PoseFromHomography(h)
+	if err != nil {
+		log.Fatalf("Unable to find the camera pose: %v\n", err)
+	}
+	lens.Pose = &p
+	if err := lens.Save(*calibPath); err != nil {
+		log.Fatalf("Unable to write %v: %v\n", *calibPath, err)
+	}
+	fmt.Printf("Camera at %v from the checkerboard, writing %v\n", p.T, *calibPath)
+}
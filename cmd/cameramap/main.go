@@ -5,14 +5,18 @@ import (
 	"flag"
 	"fmt"
 	"image"
-	"image/color"
 	"log"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 
-	"github.com/tarm/serial"
+	"github.com/tgreiser/cymapper/layout"
+	"github.com/tgreiser/cymapper/mapper"
+	"github.com/tgreiser/cymapper/output"
+	"github.com/tgreiser/cymapper/warp"
 	"gocv.io/x/gocv"
 )
 
@@ -25,190 +29,199 @@ var deviceID = flag.Int("device-id", 0, "Device ID of your webcam")
 var comPort = flag.String("com", "COM8", "COM port for teensy")
 var delayMs = flag.Int("delay-ms", 1000, "Number of milliseconds to pause on each LED")
 var startPin = flag.Int("start-pin", 1, "Skip to a certain pin")
+var extraDeviceIDs = flag.String("extra-device-ids", "", "Comma separated webcams to map at the same time, each saved next to -file with a -cam<ID> suffix, for cmd/triangulate")
+var calibration = flag.String("calibration", "", "Lens calibration file from cmd/calibrate, used to undistort detected points. Comma separated for -device-id then each of -extra-device-ids")
+var layoutSpec = flag.String("layout", "", "LEDs on each pin as count[:order][:r][:16], comma separated, e.g. 150:GRB,2x150,720:RGB:r,60:RGBW:16. Overrides -pins and -leds")
+
+// wiring of the LEDs, from -layout or -pins strips of -leds RGB LEDs
+var lay *layout.Layout
+
+// camera is one viewpoint of the LEDs and the map it is saved to
+type camera struct {
+	id     int
+	path   string
+	lens   *warp.Lens // nil when not calibrated
+	webcam *gocv.VideoCapture
+}
 
-// Illuminate each LED one at a time, in sequence.
-var counter = 0
-var max = 0
-
-// Return a buffer of bytes, leds * pins * 3
-var bufLen = 0
-
-// color for the rect when light detected
-var blue = color.RGBA{0, 0, 255, 0}
-
-var ticker *time.Ticker
-var stop = false
-var width = 0
-var height = 0
+// mapResult is the outcome of a mapping run
+type mapResult struct {
+	maps [][]image.Point
+	err  error
+}
 
 func init() {
 	flag.Parse()
-	ticker = time.NewTicker(time.Millisecond * time.Duration(*delayMs))
 
-	// ensure radius is above 0 and an odd number
-	if *radius < 1 {
-		*radius = 1
-	}
-	if *radius%2 == 0 {
-		*radius = *radius + 1
+	lay = layout.Uniform(*pins, *leds)
+	if *layoutSpec != "" {
+		var err error
+		lay, err = layout.Parse(*layoutSpec)
+		if err != nil {
+			log.Fatalf("Bad layout %v: %v\n", *layoutSpec, err)
+		}
 	}
-
-	max = *leds * *pins
-	// Return a buffer of bytes, leds * pins * 3
-	bufLen = max * 3
-	counter = (*startPin - 1) * *leds * 3
 }
 
 func main() {
 	// Serial configuration for teensy
-	c := &serial.Config{Name: *comPort, Baud: 256000}
-	s, err := serial.OpenPort(c)
+	out, err := output.OpenSerial(*comPort, output.DefaultBaud)
 	if err != nil {
 		log.Fatalf("When connecting to port: %v: %v", *comPort, err)
 	}
-	defer s.Close()
+	defer out.Close()
 
-	// open webcam
-	webcam, err := gocv.VideoCaptureDevice(int(*deviceID))
-	if err != nil {
-		fmt.Printf("error opening video capture device: %v\n", deviceID)
-		return
+	cams := openCameras()
+	defer func() {
+		for _, c := range cams {
+			c.webcam.Close()
+		}
+	}()
+	webcams := []*gocv.VideoCapture{}
+	for _, c := range cams {
+		webcams = append(webcams, c.webcam)
 	}
-	defer webcam.Close()
 
 	// open display window
 	window := gocv.NewWindow("CyMapper")
 	defer window.Close()
 
-	// prepare image matricies
-	img := gocv.NewMat()
-	defer img.Close()
-
-	gray := gocv.NewMat()
-	defer gray.Close()
-
-	// read camera dimensions
-	if ok := webcam.Read(&img); !ok {
-		fmt.Printf("cannot read device %d\n", *deviceID)
-		return
-	}
-	fmt.Printf("%d x %d\n", img.Cols(), img.Rows())
-	width = img.Cols()
-	height = img.Rows()
-
-	file, err := os.Create(*tsvPath)
-	if err != nil {
-		log.Fatalf("Unable to create %v: %v\n", *tsvPath, err)
-	}
-	defer file.Close()
-	w := csv.NewWriter(file)
-	defer w.Flush()
-	w.Comma = '\t'
-
 	// channel to receive os signal
 	cs := make(chan os.Signal, 1)
 	signal.Notify(cs, os.Interrupt)
 
-	// channel to receive camera event
-	c1 := make(chan string)
-	tick(s, c1)
+	cfg := mapper.Config{
+		Leds:       *leds,
+		Pins:       *pins,
+		Brightness: *brightness,
+		StartPin:   *startPin,
+		Delay:      time.Duration(*delayMs) * time.Millisecond,
+		Radius:     *radius,
+		Layout:     lay,
+	}
+	progress := make(chan mapper.Progress, 1)
+	done := make(chan mapResult, 1)
+	stop := make(chan struct{})
+	go func() {
+		maps, err := mapper.RunCameras(cfg, out, webcams, stop, func(p mapper.Progress) {
+			fmt.Printf("LED %v of %v at %v\n", p.Done, p.Total, p.Points)
+			// the window only needs the latest frame
+			select {
+			case <-progress:
+			default:
+			}
+			progress <- p
+		})
+		done <- mapResult{maps, err}
+	}()
 
-	fmt.Printf("start reading camera device: %v with delay %v ms\n", *deviceID, *delayMs)
-	for {
-		if ok := webcam.Read(&img); !ok {
-			fmt.Printf("cannot read device %d\n", *deviceID)
-			return
-		}
+	fmt.Printf("Mapping layout %v on camera %v with delay %v ms\n", lay, *deviceID, *delayMs)
+	var r mapResult
+	for running := true; running; {
 		select {
-		case msg := <-c1:
-			fmt.Printf("%v msg: %v\n", time.Now(), msg)
-
-			go func() {
-				pt := processFrame(window, img, gray)
-				err := w.Write([]string{strconv.Itoa(pt.X), strconv.Itoa(pt.Y)})
-				if err != nil {
-					fmt.Printf("Can not write TSV data: %v\n", err)
-				}
-				window.IMShow(img)
-			}()
-
-			if msg == "stop" {
-				ticker.Stop()
-				stop = true
-			}
-		case _ = <-cs:
-			ticker.Stop()
-			stop = true
-		default:
-			window.IMShow(img)
+		case p := <-progress:
+			show(window, p.Frame)
+		case r = <-done:
+			running = false
+		case <-cs:
+			// stop after the LED being detected, the points so far are saved
+			close(stop)
+			cs = nil
 		}
-
 		window.WaitKey(1)
-		if stop == true {
-			break
+	}
+	if r.err != nil {
+		fmt.Printf("Mapping stopped after %v LEDs: %v\n", len(r.maps[0]), r.err)
+	}
+
+	for iC, c := range cams {
+		if err := writeMap(c.path, r.maps[iC], c.lens); err != nil {
+			log.Fatalf("Unable to create %v: %v\n", c.path, err)
 		}
+		fmt.Printf("Saved %v LEDs from camera %v to %v\n", len(r.maps[iC]), c.id, c.path)
 	}
 	fmt.Println("Done")
 }
 
-func tick(s *serial.Port, c1 chan string) {
-	dur := time.Duration(*delayMs/2) * time.Millisecond
-	// start a routine to activate the LEDs
-	go func() {
-		for _ = range ticker.C {
-			ledSequence(s, c1)
-			fmt.Printf("Take picture in %v ms\n", dur.Seconds()*1000)
-			time.AfterFunc(dur, func() {
-				c1 <- "tick"
-			})
+// openCameras opens -device-id and every webcam in -extra-device-ids, with
+// the map file and calibration of each
+func openCameras() []camera {
+	cams := []camera{{id: *deviceID, path: *tsvPath}}
+	if *extraDeviceIDs != "" {
+		ext := filepath.Ext(*tsvPath)
+		for _, field := range strings.Split(*extraDeviceIDs, ",") {
+			id, err := strconv.Atoi(strings.TrimSpace(field))
+			if err != nil {
+				log.Fatalf("Bad device ID %v: %v\n", field, err)
+			}
+			path := fmt.Sprintf("%v-cam%v%v", strings.TrimSuffix(*tsvPath, ext), id, ext)
+			fmt.Printf("Also mapping camera %v to %v\n", id, path)
+			cams = append(cams, camera{id: id, path: path})
 		}
-	}()
-}
-
-func processFrame(window *gocv.Window, img, gray gocv.Mat) *image.Point {
-	if img.Empty() {
-		return nil
 	}
 
-	gocv.CvtColor(img, &gray, gocv.ColorRGBToGray)
-	gocv.GaussianBlur(gray, &gray, image.Point{X: *radius, Y: *radius}, 0, 0, gocv.BorderDefault)
-
-	// detect brightest point
-	_, _, _, maxLoc := gocv.MinMaxLoc(gray)
-
-	// draw a rectangle around the bright spot
-	gocv.Rectangle(&img, image.Rect(maxLoc.X-6, maxLoc.Y-6, maxLoc.X+6, maxLoc.Y+6), blue, 3)
-
-	// show the image in the window, and wait 1 millisecond
-	//window.IMShow(img)
-	//window.WaitKey(1)
+	if *calibration != "" {
+		// every map must be undistorted the same way for cmd/triangulate
+		paths := strings.Split(*calibration, ",")
+		if len(paths) != len(cams) {
+			log.Fatalf("%v calibrations for %v cameras, give one for each\n", len(paths), len(cams))
+		}
+		for iC, path := range paths {
+			lens, err := warp.LoadLens(path)
+			if err != nil {
+				log.Fatalf("Unable to load calibration %v: %v\n", path, err)
+			}
+			cams[iC].lens = lens
+		}
+	}
 
-	fmt.Printf("%d x %d\n", maxLoc.X, maxLoc.Y)
-	return &maxLoc
+	for iC := range cams {
+		webcam, err := gocv.VideoCaptureDevice(cams[iC].id)
+		if err != nil {
+			log.Fatalf("error opening video capture device: %v\n", cams[iC].id)
+		}
+		cams[iC].webcam = webcam
+	}
+	return cams
 }
 
-func ledSequence(s *serial.Port, c chan string) {
-	fmt.Printf("Running ledSequence with %d pins, %d LEDs, %d total, %d count\n", *pins, *leds, max, counter)
-	buf := make([]byte, bufLen, bufLen)
-
-	for iX := 0; iX < bufLen; iX++ {
-		if iX >= counter && iX < counter+3 {
-			buf[iX] = byte(*brightness)
-		} else {
-			buf[iX] = 0
-		}
+// show draws a marked camera frame in the window
+func show(window *gocv.Window, frame *image.RGBA) {
+	if frame == nil {
+		return
 	}
-	counter = counter + 3
-	if counter >= bufLen {
-		counter = 0
-		fmt.Printf("Finished sequence, ending %d\n", bufLen)
-		c <- "stop"
+	mat, err := gocv.ImageToMatRGBA(frame)
+	if err != nil {
+		return
 	}
+	defer mat.Close()
+	gocv.CvtColor(mat, &mat, gocv.ColorRGBAToBGR)
+	window.IMShow(mat)
+}
 
-	// send to the teensy via serial
-	//log.Printf("sending %v bytes\n", len(buf))
-	_, err := s.Write(buf)
+// writeMap saves detected points as TSV, undistorted when the camera has a
+// lens calibration
+func writeMap(path string, pts []image.Point, lens *warp.Lens) error {
+	file, err := os.Create(path)
 	if err != nil {
-		log.Printf("Serial write error: %v\n", err)
+		return err
+	}
+	defer file.Close()
+	w := csv.NewWriter(file)
+	w.Comma = '\t'
+	for _, pt := range pts {
+		w.Write(formatPoint(pt, lens))
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// formatPoint returns the TSV fields for a detected point, undistorted when
+// the camera has a lens calibration
+func formatPoint(pt image.Point, lens *warp.Lens) []string {
+	if lens == nil {
+		return []string{strconv.Itoa(pt.X), strconv.Itoa(pt.Y)}
 	}
+	u := lens.Undistort(warp.Point{X: float64(pt.X), Y: float64(pt.Y)})
+	return []string{strconv.FormatFloat(u.X, 'f', 2, 64), strconv.FormatFloat(u.Y, 'f', 2, 64)}
 }
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/tgreiser/cymapper/audio"
+	"github.com/tgreiser/cymapper/cmd/scenebuild/fixture"
+	"github.com/tgreiser/cymapper/correct"
+	"github.com/tgreiser/cymapper/effects"
+	"github.com/tgreiser/cymapper/layout"
+	"github.com/tgreiser/cymapper/output"
+)
+
+var playlist = flag.String("playlist", "plasma rainbow pulse noise fire", "Patterns to play in turn, separated by spaces, each like fire:speed=0.6,scale=3,duration=60")
+var duration = flag.Float64("duration", 30, "Seconds each pattern plays when it has no duration")
+var fade = flag.Float64("fade", 3, "Seconds to crossfade from one pattern to the next")
+var fps = flag.Float64("fps", 30, "Frames per second")
+var gamma = flag.Float64("gamma", 2.2, "Gamma correction, 1 for none")
+var brightness = flag.Float64("brightness", 1, "Brightness (0-1)")
+var vwidth = flag.Float64("vwidth", 1280, "Width of the scene, 0 to fit the points")
+var vheight = flag.Float64("vheight", 720, "Height of the scene, 0 to fit the points")
+var yDown = flag.Bool("y-down", false, "Map y runs downward like the camera image instead of up like scenebuild")
+var leds = flag.Int("leds", 0, "Total LEDs the controller drives, 0 sends just the mapped LEDs")
+var list = flag.Bool("list", false, "List the patterns and their settings")
+var audioPath = flag.String("audio", "", "WAV file, or - for raw 16 bit PCM on stdin, for audio-reactive patterns")
+var audioRate = flag.Int("audio-rate", 44100, "Sample rate of raw PCM on stdin")
+var audioChannels = flag.Int("audio-channels", 2, "Channels of raw PCM on stdin")
+var bands = flag.Int("bands", 16, "Frequency bands measured for the spectrum pattern")
+
+// -output, -layout and the colour corrections, shared with play and
+// testpattern
+var outFlags = output.AddFlags(flag.CommandLine)
+
+// audio analysis window and step, in samples
+const (
+	fftSize = 2048
+	fftHop  = 512
+)
+
+func init() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: effects [options] map.tsv [map.tsv ...]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+}
+
+/**
+ * Run generated patterns on the mapped LEDs without a media server
+ */
+func main() {
+	if *list {
+		for _, name := range effects.Names() {
+			fmt.Printf("%v: %v\n", name, strings.Join(append(effects.Settings(name), "duration"), ", "))
+		}
+		fmt.Printf("with -audio, every pattern: %v=0\n", strings.Join(effects.Inputs(), "=0, "))
+		return
+	}
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *fps <= 0 {
+		log.Fatalf("-fps must be positive\n")
+	}
+
+	var sound *effects.Sound
+	var heard <-chan audio.Features
+	if *audioPath != "" {
+		sound = &effects.Sound{}
+		heard = listen()
+	}
+
+	pl := &effects.Playlist{Fade: *fade}
+	for _, spec := range strings.Fields(*playlist) {
+		e, err := effects.ParseEntry(spec, *duration, sound)
+		if err != nil {
+			log.Fatalf("Bad playlist entry %v\n", err)
+		}
+		pl.Entries = append(pl.Entries, e)
+	}
+	if len(pl.Entries) == 0 {
+		log.Fatalf("The playlist is empty\n")
+	}
+
+	pts, counts := loadPoints(flag.Args())
+
+	total := len(pts)
+	if *leds > total {
+		total = *leds
+	}
+	lay, err := outFlags.Wiring(layout.Uniform(1, total))
+	if err != nil {
+		log.Fatalf("Bad layout %v: %v\n", *outFlags.Layout, err)
+	}
+	out, err := outFlags.Open(lay, correct.NewPipeline(*gamma, *brightness), counts)
+	if err != nil {
+		log.Fatalf("Unable to open output: %v\n", err)
+	}
+	defer out.Close()
+	frame := make([]byte, total*3)
+	fmt.Printf("Playing %v patterns on %v LEDs to %v\n", len(pl.Entries), len(pts), *outFlags.Spec)
+
+	cs := make(chan os.Signal, 1)
+	signal.Notify(cs, os.Interrupt)
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / *fps))
+	defer ticker.Stop()
+
+	started := time.Now()
+	playing := -1
+	for {
+		select {
+		case <-ticker.C:
+		case <-cs:
+			// leave the LEDs off
+			out.Write(make([]byte, len(frame)))
+			fmt.Println("Stopped")
+			return
+		}
+		t := time.Since(started).Seconds()
+		for more := heard != nil; more; {
+			select {
+			case f := <-heard:
+				sound.Record(t, level(f))
+			default:
+				more = false
+			}
+		}
+		if iE, _ := pl.Current(t); iE != playing {
+			playing = iE
+			fmt.Printf("%v %v\n", time.Now().Format("15:04:05"), pl.Entries[iE].Name)
+		}
+		effects.Frame(pl, pts, t, frame)
+		if err := out.Write(frame); err != nil {
+			log.Fatalf("Output write error: %v\n", err)
+		}
+	}
+}
+
+// listen analyses -audio in the background. Audio is read no faster than
+// real time, so a file sounds as if it was playing.
+func listen() <-chan audio.Features {
+	var stream *audio.Stream
+	if *audioPath != "-" {
+		file, err := os.Open(*audioPath)
+		if err != nil {
+			log.Fatalf("Unable to open %v: %v\n", *audioPath, err)
+		}
+		if stream, err = audio.OpenWAV(file); err != nil {
+			log.Fatalf("Unable to read %v: %v\n", *audioPath, err)
+		}
+	} else {
+		stream = audio.NewRaw(os.Stdin, *audioRate, *audioChannels)
+	}
+	analyzer, err := audio.NewAnalyzer(stream.Rate, fftSize, fftHop, *bands)
+	if err != nil {
+		log.Fatalf("Unable to analyse %v: %v\n", *audioPath, err)
+	}
+	fmt.Printf("Listening to %v at %v Hz\n", *audioPath, stream.Rate)
+
+	heard := make(chan audio.Features, 64)
+	go func() {
+		block := make([]float64, analyzer.Hop())
+		hop := time.Duration(float64(time.Second) * float64(len(block)) / float64(stream.Rate))
+		next := time.Now()
+		for {
+			n, err := stream.Read(block)
+			if err != nil {
+				fmt.Printf("Audio ended: %v\n", err)
+				// go quiet rather than hold the last sound
+				heard <- audio.Features{}
+				return
+			}
+			next = next.Add(hop)
+			time.Sleep(time.Until(next))
+			select {
+			case heard <- analyzer.Add(block[:n]):
+			default:
+				// frames are not keeping up, skip the measurement
+			}
+		}
+	}()
+	return heard
+}
+
+// level converts audio features to what the patterns hear
+func level(f audio.Features) effects.Level {
+	l := effects.Level{Level: f.Level, Bass: f.Bass, Mid: f.Mid, Treble: f.Treble, Bands: f.Bands}
+	if f.Beat {
+		l.Beat = 1
+	}
+	return l
+}
+
+// loadPoints joins map or scene files in address order normalised to the
+// scene with y down, and returns the LEDs in each file. Depth is normalised
+// to the depth of the points.
+func loadPoints(paths []string) ([]effects.Point, []int) {
+	fixtures := []*fixture.Fixture{}
+	counts := []int{}
+	for _, path := range paths {
+		f := fixture.NewFixture(path)
+		fixtures = append(fixtures, f)
+		counts = append(counts, f.Length())
+	}
+	vs := fixture.NewScene(fixtures).Points()
+
+	lo := [3]float64{math.Inf(1), math.Inf(1), math.Inf(1)}
+	hi := [3]float64{math.Inf(-1), math.Inf(-1), math.Inf(-1)}
+	for _, v := range vs {
+		for iD, c := range []float32{v.X, v.Y, v.Z} {
+			lo[iD] = math.Min(lo[iD], float64(c))
+			hi[iD] = math.Max(hi[iD], float64(c))
+		}
+	}
+	// the scene frame, or the bounds of the points
+	x0, y0, w, h := 0.0, 0.0, *vwidth, *vheight
+	if w <= 0 {
+		x0, w = lo[0], hi[0]-lo[0]
+	}
+	if h <= 0 {
+		y0, h = lo[1], hi[1]-lo[1]
+	}
+	norm := func(v, start, size float64) float64 {
+		if size <= 0 {
+			return 0
+		}
+		return (v - start) / size
+	}
+
+	pts := make([]effects.Point, len(vs))
+	for iV, v := range vs {
+		y := norm(float64(v.Y), y0, h)
+		if !*yDown {
+			y = 1 - y
+		}
+		pts[iV] = effects.Point{
+			X: norm(float64(v.X), x0, w),
+			Y: y,
+			Z: norm(float64(v.Z), lo[2], hi[2]-lo[2]),
+		}
+	}
+	return pts, counts
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image/png"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/tgreiser/cymapper/cmd/scenebuild/fixture"
+	"github.com/tgreiser/cymapper/export"
+	"github.com/tgreiser/cymapper/layout"
+)
+
+var outPath = flag.String("file", "", "Filename for the export (default stdout)")
+var format = flag.String("format", "pixelblaze", "Export format: pixelblaze, fastled, resolume, madmapper or touchdesigner")
+var normalize = flag.Bool("normalize", false, "Pixelblaze: scale coordinates into 0..1")
+var threeD = flag.Bool("3d", false, "Pixelblaze: write x, y, z coordinates")
+var gridWidth = flag.Int("grid-width", 32, "FastLED: width of the XY() grid")
+var gridHeight = flag.Int("grid-height", 32, "FastLED: height of the XY() grid")
+var vwidth = flag.Int("vwidth", 1280, "Width of the video stream you will be mapping")
+var vheight = flag.Int("vheight", 720, "Height of the video stream you will be mapping")
+var yDown = flag.Bool("y-down", false, "Map y runs downward like the camera image instead of up like scenebuild")
+var universe = flag.Int("universe", 0, "Resolume/MadMapper: universe of the first pixel")
+var fixtureUniverse = flag.Bool("fixture-universe", false, "Resolume/MadMapper: start each map file on a new universe")
+var layoutSpec = flag.String("layout", "", "Resolume/MadMapper: LEDs on each pin as count[:order][:r][:16], so RGBW and 16 bit pixels are patched like the Art-Net output")
+var texturePath = flag.String("texture", "", "TouchDesigner: also write a 16 bit PNG uv lookup texture")
+var textureWidth = flag.Int("texture-width", 0, "TouchDesigner: texels per row of the lookup texture (default one row)")
+
+func init() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: export [options] map.tsv [map.tsv ...]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+}
+
+/**
+ * Read one or more map or scene TSV files and export them, in order, as a
+ * single map for another LED tool.
+ */
+func main() {
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	fixtures := []*fixture.Fixture{}
+	for _, path := range flag.Args() {
+		fixtures = append(fixtures, fixture.NewFixture(path))
+	}
+	scene := fixture.NewScene(fixtures)
+
+	var out io.Writer = os.Stdout
+	if *outPath != "" {
+		file, err := os.Create(*outPath)
+		if err != nil {
+			log.Fatalf("Unable to create %v: %v\n", *outPath, err)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	var err error
+	switch *format {
+	case "pixelblaze":
+		err = export.WritePixelblaze(out, scene.Points(), export.PixelblazeOptions{
+			Normalize: *normalize,
+			ThreeD:    *threeD,
+		})
+	case "fastled":
+		err = export.WriteFastLED(out, scene.Points(), export.FastLEDOptions{
+			Width:  *gridWidth,
+			Height: *gridHeight,
+			Source: filepath.Base(flag.Arg(0)),
+		})
+	case "resolume":
+		err = export.WriteResolume(out, scene, export.ResolumeOptions{
+			PatchOptions: patchOptions(),
+			Name:         filepath.Base(flag.Arg(0)),
+			Width:        *vwidth,
+			Height:       *vheight,
+			YDown:        *yDown,
+		})
+	case "madmapper":
+		err = export.WriteMadMapper(out, scene, export.MadMapperOptions{
+			PatchOptions: patchOptions(),
+			Width:        *vwidth,
+			Height:       *vheight,
+			YDown:        *yDown,
+		})
+	case "touchdesigner":
+		opts := export.TouchDesignerOptions{
+			Width:        float32(*vwidth),
+			Height:       float32(*vheight),
+			YDown:        *yDown,
+			TextureWidth: *textureWidth,
+		}
+		err = export.WriteTouchDesignerTable(out, scene.Points(), opts)
+		if err == nil && *texturePath != "" {
+			err = writeTexture(*texturePath, scene, opts)
+		}
+	default:
+		log.Fatalf("Unknown format: %v\n", *format)
+	}
+	if err != nil {
+		log.Fatalf("Export failed: %v\n", err)
+	}
+}
+
+func patchOptions() export.PatchOptions {
+	opts := export.PatchOptions{
+		StartUniverse:    *universe,
+		ChannelsPerPixel: 3,
+		FixtureUniverse:  *fixtureUniverse,
+	}
+	if *layoutSpec != "" {
+		lay, err := layout.Parse(*layoutSpec)
+		if err != nil {
+			log.Fatalf("Bad layout %v: %v\n", *layoutSpec, err)
+		}
+		opts.Layout = lay
+	}
+	return opts
+}
+
+func writeTexture(path string, scene *fixture.Scene, opts export.TouchDesignerOptions) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return png.Encode(file, export.TouchDesignerTexture(scene.Points(), opts))
+}
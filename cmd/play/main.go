@@ -0,0 +1,218 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tgreiser/cymapper/cmd/scenebuild/fixture"
+	"github.com/tgreiser/cymapper/correct"
+	"github.com/tgreiser/cymapper/layout"
+	"github.com/tgreiser/cymapper/output"
+	"github.com/tgreiser/cymapper/sample"
+	"github.com/tgreiser/cymapper/source"
+	"github.com/tgreiser/cymapper/source/capture"
+	"github.com/tgreiser/cymapper/warp"
+)
+
+var videoPath = flag.String("video", "", "Video file, image sequence like frames/%04d.png, capture device like /dev/video10, or - for raw RGB frames on stdin")
+var deviceID = flag.Int("device-id", -1, "Play a capture device such as a V4L2 loopback instead of -video")
+var rawWidth = flag.Int("raw-width", 1280, "Width of raw RGB frames on stdin")
+var rawHeight = flag.Int("raw-height", 720, "Height of raw RGB frames on stdin")
+var region = flag.String("region", "", "Only play this region of the frames, x,y,width,height in frame pixels")
+var fps = flag.Float64("fps", 0, "Frames per second, 0 plays at the video frame rate, or as live frames arrive")
+var sampling = flag.String("sample", "bilinear", "How LEDs read the video: nearest, bilinear or area")
+var radius = flag.Float64("radius", 2, "Area sampling: half the size of the averaged box, in map pixels")
+var gamma = flag.Float64("gamma", 2.2, "Gamma correction, 1 for none")
+var brightness = flag.Float64("brightness", 1, "Brightness (0-1)")
+var loop = flag.Bool("loop", false, "Start over at the end of the video")
+var vwidth = flag.Float64("vwidth", 1280, "Width of the video the map was made for, 0 to use the map as frame pixels")
+var vheight = flag.Float64("vheight", 720, "Height of the video the map was made for")
+var yDown = flag.Bool("y-down", false, "Map y runs downward like the camera image instead of up like scenebuild")
+var leds = flag.Int("leds", 0, "Total LEDs the controller drives, 0 sends just the mapped LEDs")
+
+// -output, -layout and the colour corrections, shared with effects and
+// testpattern
+var outFlags = output.AddFlags(flag.CommandLine)
+
+// fallbackFPS is used when neither -fps nor a video file give a frame rate
+const fallbackFPS = 30
+
+func init() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: play [options] -video clip.mp4 map.tsv [map.tsv ...]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+}
+
+/**
+ * Play a video on the LEDs, sampled at the mapped position of each LED
+ */
+func main() {
+	if flag.NArg() == 0 || (*videoPath == "" && *deviceID < 0) {
+		flag.Usage()
+		os.Exit(2)
+	}
+	pts, counts := loadPoints(flag.Args())
+
+	mode, err := sample.ParseMode(*sampling)
+	if err != nil {
+		log.Fatalf("%v\n", err)
+	}
+	opts := sample.Options{Mode: mode, Radius: *radius, Width: *vwidth, Height: *vheight}
+
+	video, name, live := openSource()
+	defer video.Close()
+	// a bad -region fails on the first frame, before anything is sent
+	img, err := video.Read()
+	if err != nil {
+		log.Fatalf("Unable to read %v: %v\n", name, err)
+	}
+	if rect, err := parseRegion(*region); err == nil && img.Bounds() != rect {
+		fmt.Printf("Region %v is clipped to the frame, playing %v\n", rect, img.Bounds())
+	}
+
+	total := len(pts)
+	if *leds > total {
+		total = *leds
+	}
+	lay, err := outFlags.Wiring(layout.Uniform(1, total))
+	if err != nil {
+		log.Fatalf("Bad layout %v: %v\n", *outFlags.Layout, err)
+	}
+	out, err := outFlags.Open(lay, correct.NewPipeline(*gamma, *brightness), counts)
+	if err != nil {
+		log.Fatalf("Unable to open output: %v\n", err)
+	}
+	defer out.Close()
+	frame := make([]byte, total*3)
+
+	rate := *fps
+	if rate <= 0 {
+		rate = video.FPS()
+	}
+	if rate <= 0 && !live {
+		rate = fallbackFPS
+	}
+	// live frames are sent as they arrive unless a rate is known, a closed
+	// channel never waits
+	always := make(chan time.Time)
+	close(always)
+	var tick <-chan time.Time = always
+	if rate > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / rate))
+		defer ticker.Stop()
+		tick = ticker.C
+		fmt.Printf("Playing %v to %v LEDs on %v at %.2f FPS\n", name, len(pts), *outFlags.Spec, rate)
+	} else {
+		fmt.Printf("Playing %v to %v LEDs on %v as frames arrive\n", name, len(pts), *outFlags.Spec)
+	}
+
+	cs := make(chan os.Signal, 1)
+	signal.Notify(cs, os.Interrupt)
+
+	count := 0
+	for {
+		sample.Frame(img, pts, opts, frame)
+
+		select {
+		case <-tick:
+		case <-cs:
+			out.Write(make([]byte, len(frame)))
+			fmt.Printf("Stopped after %v frames\n", count)
+			return
+		}
+		if err := out.Write(frame); err != nil {
+			log.Fatalf("Output write error: %v\n", err)
+		}
+		count++
+
+		img, err = video.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Fatalf("Unable to read %v: %v\n", name, err)
+		}
+	}
+	// leave the LEDs off
+	out.Write(make([]byte, len(frame)))
+	fmt.Printf("Done, %v frames\n", count)
+}
+
+// openSource opens the frames chosen by the flags, cropped to -region. Live
+// sources have no end and no frame rate of their own.
+func openSource() (video source.Source, name string, live bool) {
+	var err error
+	switch {
+	case *deviceID >= 0:
+		name, live = fmt.Sprintf("device %v", *deviceID), true
+		video, err = capture.OpenDevice(*deviceID)
+	case *videoPath == "-":
+		name, live = "stdin", true
+		video = source.NewRaw(os.Stdin, *rawWidth, *rawHeight, 0)
+	default:
+		name, live = *videoPath, strings.HasPrefix(*videoPath, "/dev/")
+		video, err = capture.OpenVideo(*videoPath, *loop)
+	}
+	if err != nil {
+		log.Fatalf("Unable to open %v: %v\n", name, err)
+	}
+	if *region != "" {
+		rect, err := parseRegion(*region)
+		if err != nil {
+			log.Fatalf("Bad region %v: %v\n", *region, err)
+		}
+		video = source.Crop{Source: video, Rect: rect}
+	}
+	return video, name, live
+}
+
+// parseRegion reads x,y,width,height
+func parseRegion(s string) (image.Rectangle, error) {
+	fields := strings.Split(s, ",")
+	if len(fields) != 4 {
+		return image.Rectangle{}, fmt.Errorf("expected x,y,width,height")
+	}
+	v := [4]int{}
+	for iF, field := range fields {
+		n, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil {
+			return image.Rectangle{}, err
+		}
+		v[iF] = n
+	}
+	if v[2] <= 0 || v[3] <= 0 {
+		return image.Rectangle{}, fmt.Errorf("width and height must be positive")
+	}
+	return image.Rect(v[0], v[1], v[0]+v[2], v[1]+v[3]), nil
+}
+
+// loadPoints joins map or scene files in address order as video pixels with
+// y down, and returns the LEDs in each file
+func loadPoints(paths []string) ([]warp.Point, []int) {
+	fixtures := []*fixture.Fixture{}
+	counts := []int{}
+	for _, path := range paths {
+		f := fixture.NewFixture(path)
+		fixtures = append(fixtures, f)
+		counts = append(counts, f.Length())
+	}
+	pts := []warp.Point{}
+	for _, v := range fixture.NewScene(fixtures).Points() {
+		p := warp.Point{X: float64(v.X), Y: float64(v.Y)}
+		if !*yDown {
+			p.Y = *vheight - p.Y
+		}
+		pts = append(pts, p)
+	}
+	return pts, counts
+}
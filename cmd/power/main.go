@@ -0,0 +1,76 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/tgreiser/cymapper/cmd/scenebuild/fixture"
+	"github.com/tgreiser/cymapper/layout"
+	"github.com/tgreiser/cymapper/power"
+)
+
+var leds = flag.Int("leds", 460, "Number of LEDs per strip (1-10000)")
+var pins = flag.Int("pins", 8, "Number of pins which have LEDs connected")
+var layoutSpec = flag.String("layout", "", "LEDs on each pin as count[:order][:r][:16], comma separated, e.g. 150:GRB,2x150,720:RGB:r,60:RGBW:16. Overrides -pins and -leds")
+var volts = flag.Float64("volts", 5, "Supply voltage of the LEDs")
+var brightness = flag.Float64("brightness", 1, "Brightness the LEDs are driven at (0-1)")
+var typical = flag.Float64("typical", 0.3, "Average level of typical content, as a share of full white (0-1)")
+var ledMilliamps = flag.Float64("led-ma", 20, "Current of one colour channel of an LED at full brightness, in milliamps")
+var idleMilliamps = flag.Float64("idle-ma", 1, "Current of an LED when it is off, in milliamps")
+var pinAmps = flag.Float64("pin-amps", 0, "Most current one pin's power injection should carry, 0 for no limit")
+var fixtureAmps = flag.Float64("fixture-amps", 0, "Most current one fixture should draw, 0 for no limit")
+var supplyAmps = flag.Float64("supply-amps", 0, "Most current the power supplies deliver together, 0 for no limit")
+
+// wiring of the LEDs, from -layout or -pins strips of -leds RGB LEDs
+var lay *layout.Layout
+
+func init() {
+	flag.Parse()
+	if flag.NArg() < 1 {
+		log.Fatalf("Usage: power [flags] <map.tsv>...\n")
+	}
+	if *brightness < 0 || *brightness > 1 || *typical < 0 || *typical > 1 {
+		log.Fatalf("-brightness and -typical must be between 0 and 1\n")
+	}
+	lay = layout.Uniform(*pins, *leds)
+	if *layoutSpec != "" {
+		var err error
+		lay, err = layout.Parse(*layoutSpec)
+		if err != nil {
+			log.Fatalf("Bad layout %v: %v\n", *layoutSpec, err)
+		}
+	}
+}
+
+/**
+ * Estimate the current each fixture and pin of a scene draws, exits 1 when
+ * any of them is over its limit
+ */
+func main() {
+	fixtures := []power.Fixture{}
+	for _, path := range flag.Args() {
+		f := fixture.NewFixture(path)
+		fixtures = append(fixtures, power.Fixture{Name: f.Name(), Pixels: f.Length()})
+	}
+
+	r := power.Estimate(fixtures, lay, power.Config{
+		Volts:            *volts,
+		ChannelMilliamps: *ledMilliamps,
+		IdleMilliamps:    *idleMilliamps,
+		Brightness:       *brightness,
+		Typical:          *typical,
+		PinAmps:          *pinAmps,
+		FixtureAmps:      *fixtureAmps,
+		SupplyAmps:       *supplyAmps,
+	})
+	fmt.Printf("Layout %v at %v V, brightness %v\n\n", lay, *volts, *brightness)
+	if err := r.Write(os.Stdout); err != nil {
+		log.Fatalf("Unable to write report: %v\n", err)
+	}
+	if over := r.Over(); len(over) > 0 {
+		fmt.Printf("\n%v over the limit, add power injection or supplies\n", len(over))
+		os.Exit(1)
+	}
+}
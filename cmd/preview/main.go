@@ -0,0 +1,71 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image/png"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tgreiser/cymapper/cmd/scenebuild/fixture"
+	"github.com/tgreiser/cymapper/render"
+)
+
+var outPath = flag.String("file", "preview.png", "Filename for the preview, .svg or .png")
+var vwidth = flag.Float64("vwidth", 1280, "Width of the scene frame, 0 to fit the points")
+var vheight = flag.Float64("vheight", 720, "Height of the scene frame, 0 to fit the points")
+var scale = flag.Float64("scale", 1, "Output pixels per scene unit")
+var labelEvery = flag.Int("label-every", 50, "Label every Nth address, 0 for no labels")
+var leds = flag.Int("leds", 0, "Number of LEDs per strip, breaks the strip lines between pins")
+var yDown = flag.Bool("y-down", false, "Draw y downward like the camera image instead of up like scenebuild")
+var boxes = flag.Bool("boxes", true, "Draw fixture bounding boxes")
+
+func init() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: preview [options] map.tsv [map.tsv ...]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+}
+
+/**
+ * Draw one or more map or scene TSV files without starting the GUI
+ */
+func main() {
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	fixtures := []*fixture.Fixture{}
+	for _, path := range flag.Args() {
+		fixtures = append(fixtures, fixture.NewFixture(path))
+	}
+	scene := fixture.NewScene(fixtures)
+
+	opts := render.DefaultOptions()
+	opts.Width = float32(*vwidth)
+	opts.Height = float32(*vheight)
+	opts.Scale = float32(*scale)
+	opts.LabelEvery = *labelEvery
+	opts.StripLength = *leds
+	opts.YDown = *yDown
+	opts.Boxes = *boxes
+
+	file, err := os.Create(*outPath)
+	if err != nil {
+		log.Fatalf("Unable to create %v: %v\n", *outPath, err)
+	}
+	defer file.Close()
+
+	if strings.ToLower(filepath.Ext(*outPath)) == ".svg" {
+		err = render.SVG(file, scene, opts)
+	} else {
+		err = png.Encode(file, render.Image(scene, opts))
+	}
+	if err != nil {
+		log.Fatalf("Unable to write %v: %v\n", *outPath, err)
+	}
+	fmt.Printf("Writing %v\n", *outPath)
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/tgreiser/cymapper/warp"
+)
+
+var refLeds = flag.String("leds", "", "Addresses of the LEDs at the top left, top right, bottom right and bottom left of the reference rectangle, e.g. 0,49,149,99")
+var quad = flag.String("quad", "", "Camera pixels of the reference corners in the same order, e.g. \"112,80 530,95 548,410 96,402\"")
+var width = flag.Float64("width", 0, "Width of the reference rectangle (default: average width of the quad)")
+var height = flag.Float64("height", 0, "Height of the reference rectangle (default: average height of the quad)")
+
+func init() {
+	flag.Parse()
+}
+
+/**
+ * Read camera TSV data from stdin, remove the perspective (keystone)
+ * distortion using four reference corners and write the result to stdout
+ */
+func main() {
+	pts, err := warp.ReadPoints(os.Stdin)
+	if err != nil {
+		log.Fatalf("Unable to read map: %v", err)
+	}
+	fmt.Fprintf(os.Stderr, "\ncymapper rectify\n")
+
+	var corners []warp.Point
+	switch {
+	case *refLeds != "":
+		corners, err = ledCorners(pts, *refLeds)
+	case *quad != "":
+		corners, err = quadCorners(*quad)
+	default:
+		err = fmt.Errorf("set the reference corners with -leds or -quad")
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	w, h := *width, *height
+	if w <= 0 {
+		w = (dist(corners[0], corners[1]) + dist(corners[3], corners[2])) / 2
+	}
+	if h <= 0 {
+		h = (dist(corners[0], corners[3]) + dist(corners[1], corners[2])) / 2
+	}
+	fmt.Fprintf(os.Stderr, "Reference corners %v to %v x %v\n", corners, w, h)
+
+	hom, err := warp.NewHomography(corners, []warp.Point{{X: 0, Y: 0}, {X: w, Y: 0}, {X: w, Y: h}, {X: 0, Y: h}})
+	if err != nil {
+		log.Fatalf("Unable to compute homography: %v", err)
+	}
+	fmt.Fprintf(os.Stderr, "Homography: %v\n", hom)
+
+	for iP, p := range pts {
+		x, y := hom.Apply(p.X, p.Y)
+		// drop floating point noise so the corners land exactly on the rectangle
+		pts[iP].X = math.Round(x*1000) / 1000
+		pts[iP].Y = math.Round(y*1000) / 1000
+	}
+	if err := warp.WritePoints(os.Stdout, pts); err != nil {
+		log.Fatalf("Can not write TSV data: %v", err)
+	}
+}
+
+// ledCorners looks up the camera position of four LED addresses
+func ledCorners(pts []warp.Point, addresses string) ([]warp.Point, error) {
+	parts := strings.Split(addresses, ",")
+	if len(parts) != 4 {
+		return nil, fmt.Errorf("expected 4 LED addresses, found %v", len(parts))
+	}
+	corners := make([]warp.Point, 4)
+	for iX, part := range parts {
+		addr, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return nil, fmt.Errorf("bad LED address %v: %v", part, err)
+		}
+		if addr < 0 || addr >= len(pts) {
+			return nil, fmt.Errorf("LED address %v is not in the map (%v LEDs)", addr, len(pts))
+		}
+		corners[iX] = pts[addr]
+	}
+	return corners, nil
+}
+
+// quadCorners parses four x,y camera pixels separated by spaces
+func quadCorners(quad string) ([]warp.Point, error) {
+	parts := strings.Fields(quad)
+	if len(parts) != 4 {
+		return nil, fmt.Errorf("expected 4 corners, found %v", len(parts))
+	}
+	corners := make([]warp.Point, 4)
+	for iX, part := range parts {
+		xy := strings.Split(part, ",")
+		if len(xy) != 2 {
+			return nil, fmt.Errorf("bad corner %v, expected x,y", part)
+		}
+		x, errX := strconv.ParseFloat(xy[0], 64)
+		y, errY := strconv.ParseFloat(xy[1], 64)
+		if errX != nil || errY != nil {
+			return nil, fmt.Errorf("bad corner %v, expected x,y", part)
+		}
+		corners[iX] = warp.Point{X: x, Y: y}
+	}
+	return corners, nil
+}
+
+func dist(a, b warp.Point) float64 {
+	return math.Hypot(b.X-a.X, b.Y-a.Y)
+}
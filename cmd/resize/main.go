@@ -6,8 +6,11 @@ import (
 	"fmt"
 	"image"
 	"log"
+	"math"
 	"os"
 	"strconv"
+
+	"github.com/tgreiser/cymapper/warp"
 )
 
 var tsvPath = flag.String("file", "remapped.tsv", "Filename for the tsv output")
@@ -16,9 +19,15 @@ var vheight = flag.Int("vheight", 720, "Height of the video stream you will be m
 var border = flag.Int("border", 4, "Unused space to leave around the outer pixels")
 var flipX = flag.Bool("flip-x", false, "Flip the image along the X axis")
 var flipY = flag.Bool("flip-y", false, "Flip the image along the Y axis")
+var fitMode = flag.String("fit", "stretch", "How the map fills the video: stretch, contain, cover or scale")
+var fixedScale = flag.Float64("scale", 1, "Video pixels per camera pixel when -fit=scale")
+var anchor = flag.String("anchor", "center", "Where the map sits when it does not fill the video: center, top, bottom, left, right, top-left, top-right, bottom-left or bottom-right")
 
 func init() {
 	flag.Parse()
+	if _, ok := warp.Anchors[*anchor]; !ok {
+		log.Fatalf("Unknown anchor: %v", *anchor)
+	}
 }
 
 /**
@@ -38,7 +47,13 @@ func main() {
 	fmt.Printf("Border from %d x %d ", b1.X, b1.Y)
 	fmt.Printf("to %d x %d\n", b2.X, b2.Y)
 
-	vsize := image.Point{X: *vwidth, Y: *vheight}
+	frame := warp.Point{X: float64(b2.X - b1.X), Y: float64(b2.Y - b1.Y)}
+	vsize := warp.Point{X: float64(*vwidth), Y: float64(*vheight)}
+	fit, err := warp.NewFit(frame, vsize, *fitMode, *fixedScale, *anchor)
+	if err != nil {
+		log.Fatalf("Unable to fit the map: %v\n", err)
+	}
+	fit.FlipX, fit.FlipY = *flipX, *flipY
 
 	file, err := os.Create(*tsvPath)
 	if err != nil {
@@ -50,23 +65,14 @@ func main() {
 	w.Comma = '\t'
 
 	fmt.Printf("Resize %v x %v to %v x %v\n", b2.X-b1.X, b2.Y-b1.Y, *vwidth, *vheight)
-	remapPointsAndWrite(pts, b1, b2, vsize, w)
+	remapPointsAndWrite(pts, b1, fit, w)
 	fmt.Printf("Writing %v\n", *tsvPath)
 }
 
-func remapPointsAndWrite(pts [][]string, b1, b2, vsize image.Point, w *csv.Writer) {
-	frame := image.Point{X: b2.X - b1.X, Y: b2.Y - b1.Y}
-	// after the scene is centered, these represent the basis vectors for the transformation
-	xmult := float64(vsize.X) / float64(frame.X)
-	if *flipX {
-		xmult = xmult * -1
-	}
-	ymult := float64(vsize.Y) / float64(frame.Y)
-	if *flipY {
-		ymult = ymult * -1
-	}
-	fmt.Printf("vsize y %v frame y %v flipY %v\n", vsize.Y, frame.Y, *flipY)
-	fmt.Printf("Transformation: X %v Y %v\n", xmult, ymult)
+func remapPointsAndWrite(pts [][]string, b1 image.Point, fit warp.Fit, w *csv.Writer) {
+	fmt.Printf("Fit %v: effective scale X %v Y %v, map covers %v x %v at %v x %v\n",
+		*fitMode, fit.XScale, fit.YScale, fit.Size.X, fit.Size.Y, fit.Offset.X, fit.Offset.Y)
+
 	for _, pt := range pts {
 		ptX, err := strconv.ParseFloat(pt[0], 64)
 		if err != nil {
@@ -77,18 +83,10 @@ func remapPointsAndWrite(pts [][]string, b1, b2, vsize image.Point, w *csv.Write
 			log.Fatalf("Bad point: %v: %v", pt[1], err)
 		}
 
-		lx := (ptX - float64(b1.X)) * xmult
-		if *flipX {
-			lx += float64(*vwidth)
-		}
-		ly := (ptY - float64(b1.Y)) * ymult
-		if *flipY {
-			ly += float64(*vheight)
-		}
-
+		v := fit.Apply(warp.Point{X: ptX - float64(b1.X), Y: ptY - float64(b1.Y)})
 		w.Write([]string{
-			strconv.FormatFloat(lx, 'f', -1, 32),
-			strconv.FormatFloat(ly, 'f', -1, 32),
+			strconv.FormatFloat(v.X, 'f', -1, 32),
+			strconv.FormatFloat(v.Y, 'f', -1, 32),
 		})
 	}
 }
@@ -106,24 +104,25 @@ func findBounds(pts [][]string) (image.Point, image.Point) {
 	var p2 = image.Point{}
 
 	for _, pt := range pts {
-		ptX, err := strconv.Atoi(pt[0])
+		// rectified maps have fractional coordinates
+		fX, err := strconv.ParseFloat(pt[0], 64)
 		if err != nil {
 			log.Fatalf("Bad point: %v: %v", pt[0], err)
 		}
-		ptY, err := strconv.Atoi(pt[1])
+		fY, err := strconv.ParseFloat(pt[1], 64)
 		if err != nil {
 			log.Fatalf("Bad point: %v: %v", pt[1], err)
 		}
-		if ptX < p1.X {
+		if ptX := int(math.Floor(fX)); ptX < p1.X {
 			p1.X = ptX
 		}
-		if ptY < p1.Y {
+		if ptY := int(math.Floor(fY)); ptY < p1.Y {
 			p1.Y = ptY
 		}
-		if ptX > p2.X {
+		if ptX := int(math.Ceil(fX)); ptX > p2.X {
 			p2.X = ptX
 		}
-		if ptY > p2.Y {
+		if ptY := int(math.Ceil(fY)); ptY > p2.Y {
 			p2.Y = ptY
 		}
 	}
@@ -0,0 +1,189 @@
+package app
+
+import (
+	"strings"
+
+	"github.com/g3n/engine/geometry"
+	"github.com/g3n/engine/gls"
+	"github.com/g3n/engine/graphic"
+	"github.com/g3n/engine/gui"
+	"github.com/g3n/engine/material"
+	"github.com/g3n/engine/math32"
+	"github.com/tgreiser/cymapper/cmd/scenebuild/fixture"
+)
+
+// addAlignControls adds the snapping options and the align and distribute
+// buttons to the bottom row of the control panel
+func (s *SceneUI) addAlignControls(cpanel *gui.Panel) {
+	gl := gui.NewLabel("Grid")
+	gl.SetPosition(4, 114)
+	gl.SetColor(darkTextColor)
+	cpanel.Add(gl)
+
+	s.grid = gui.NewEdit(36, "0")
+	s.grid.SetPosition(34, 112)
+	cpanel.Add(s.grid)
+
+	s.snapEdges = gui.NewCheckBox("Snap to edges")
+	s.snapEdges.SetPosition(76, 114)
+	s.snapEdges.SetValue(true)
+	cpanel.Add(s.snapEdges)
+
+	al := gui.NewLabel("Align")
+	al.SetPosition(184, 114)
+	al.SetColor(darkTextColor)
+	cpanel.Add(al)
+
+	x := float32(220)
+	for _, label := range []string{"Left", "Center", "Right", "Top", "Middle", "Bottom"} {
+		edge := strings.ToLower(label)
+		b := gui.NewButton(label)
+		b.SetPosition(x, 110)
+		b.SetWidth(50)
+		b.Subscribe(gui.OnClick, func(name string, ev interface{}) {
+			s.arrange(2, func(fixtures []*fixture.Fixture) {
+				fixture.Align(fixtures, edge)
+			})
+		})
+		cpanel.Add(b)
+		x += 54
+	}
+
+	for _, axis := range []string{"X", "Y"} {
+		axis := axis
+		b := gui.NewButton("Distribute " + axis)
+		b.SetPosition(x+4, 110)
+		b.SetWidth(80)
+		b.Subscribe(gui.OnClick, func(name string, ev interface{}) {
+			s.arrange(3, func(fixtures []*fixture.Fixture) {
+				fixture.Distribute(fixtures, axis)
+			})
+		})
+		cpanel.Add(b)
+		x += 84
+	}
+}
+
+// arrange runs fn on the selected fixtures when enough are selected,
+// recording the changes as one step
+func (s *SceneUI) arrange(least int, fn func([]*fixture.Fixture)) {
+	sel := s.selection()
+	if len(sel) < least {
+		s.app.ed.Show("Ctrl-click to select more fixtures")
+		return
+	}
+	fixtures := make([]*fixture.Fixture, len(sel))
+	before := make([]fixture.State, len(sel))
+	for iS, iX := range sel {
+		fixtures[iS] = s.fixtures[iX]
+		before[iS] = s.fixtures[iX].State()
+	}
+	fn(fixtures)
+	s.recordFixtures(sel, before)
+	s.SetCorners()
+	s.Draw()
+}
+
+// selection returns the indexes of the selected fixture and any fixtures
+// ctrl-clicked into the selection, in list order
+func (s *SceneUI) selection() []int {
+	var sel []int
+	for iX, f := range s.fixtures {
+		if iX == s.selected || s.group[f] {
+			sel = append(sel, iX)
+		}
+	}
+	return sel
+}
+
+// toggleSelection adds fixture iX to the selection, or removes it when it is
+// already selected
+func (s *SceneUI) toggleSelection(iX int) {
+	if iX < 0 {
+		return
+	}
+	if s.group == nil {
+		s.group = map[*fixture.Fixture]bool{}
+	}
+	if s.selected >= 0 {
+		s.group[s.CurrentFixture()] = true
+	}
+	f := s.fixtures[iX]
+	if !s.group[f] {
+		s.group[f] = true
+		s.selectFixture(iX)
+		return
+	}
+	delete(s.group, f)
+	if iX != s.selected {
+		s.Draw()
+		return
+	}
+	// the handles move to another selected fixture
+	next := -1
+	for iF, g := range s.fixtures {
+		if s.group[g] {
+			next = iF
+		}
+	}
+	group := s.group
+	s.selectFixture(next)
+	s.group = group
+	s.Draw()
+}
+
+// snapper returns the grid and edges that dragged fixtures snap to. The
+// fixtures being dragged are skipped.
+func (s *SceneUI) snapper(skip []int) fixture.Snapper {
+	sn := fixture.Snapper{
+		Grid:  ParseFloat32(s.grid.Text(), 0),
+		Reach: handlePixels * s.scenePerPixel(),
+	}
+	if !s.snapEdges.Value() {
+		return sn
+	}
+	sn.X = []float32{0, s.sceneWidth / 2, s.sceneWidth}
+	sn.Y = []float32{0, s.sceneHeight / 2, s.sceneHeight}
+	for iX, f := range s.fixtures {
+		if contains(skip, iX) {
+			continue
+		}
+		xs, ys := fixture.Edges(f)
+		sn.X = append(sn.X, xs...)
+		sn.Y = append(sn.Y, ys...)
+	}
+	return sn
+}
+
+func contains(list []int, v int) bool {
+	for _, l := range list {
+		if l == v {
+			return true
+		}
+	}
+	return false
+}
+
+// DrawOutline draws a box around a fixture that is part of the selection
+func (s *SceneUI) DrawOutline(f *fixture.Fixture) {
+	tl, br := f.TransformedTopLeft(), f.TransformedBottomRight()
+	geom := geometry.NewGeometry()
+	vertices := math32.NewArrayF32(0, 24)
+	vertices.Append(
+		tl.X, tl.Y, 0,
+		br.X, tl.Y, 0,
+		br.X, tl.Y, 0,
+		br.X, br.Y, 0,
+		br.X, br.Y, 0,
+		tl.X, br.Y, 0,
+		tl.X, br.Y, 0,
+		tl.X, tl.Y, 0,
+	)
+	colors := math32.NewArrayF32(0, 24)
+	for iV := 0; iV < 8; iV++ {
+		colors.Append(1, 1, 0)
+	}
+	geom.AddVBO(gls.NewVBO(vertices).AddAttrib(gls.VertexPosition))
+	geom.AddVBO(gls.NewVBO(colors).AddAttrib(gls.VertexColor))
+	s.app.Scene().Add(graphic.NewLines(geom, material.NewBasic()))
+}
@@ -27,6 +27,7 @@ type App struct {
 	ambLight                 *light.Ambient
 	zoom                     *gui.Slider
 	screen                   IScreen  // Current IScreen being rendered
+	scene                    *SceneUI // Scene editor, kept while other screens are shown
 	finalizers               []func() // List of finalizers functions
 }
 
@@ -109,9 +110,9 @@ func Create() *App {
 	// Setup scene
 	app.setupScene()
 
-	sui := &SceneUI{}
-	sui.Initialize(app)
-	app.screen = sui
+	app.scene = &SceneUI{}
+	app.scene.Initialize(app)
+	app.screen = app.scene
 
 	// Subscribe to before render events to call current screen Render method
 	app.Subscribe(application.OnBeforeRender, func(evname string, ev interface{}) {
@@ -141,6 +142,13 @@ func Create() *App {
 	return app
 }
 
+// showScene returns to the scene editor with its fixtures
+func (app *App) showScene() {
+	app.setupScene()
+	app.scene.Initialize(app)
+	app.screen = app.scene
+}
+
 // AddFinalizer adds a function which will be executed before another screen is started
 func (app *App) AddFinalizer(f func()) {
 
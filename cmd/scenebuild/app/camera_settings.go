@@ -3,41 +3,56 @@ package app
 import (
 	"fmt"
 	"image"
-	"os"
+	"image/color"
+	"strconv"
+	"time"
 
 	"github.com/g3n/engine/gui"
 	"github.com/g3n/engine/math32"
+	"github.com/tgreiser/cymapper/detect"
 	"gocv.io/x/gocv"
 )
 
+// maxSpots limits the bright spots marked on the live view
+const maxSpots = 50
+
+// colors for the live view overlay
+var spotColor = color.RGBA{0, 0, 255, 0}
+var crosshairColor = color.RGBA{0, 255, 0, 0}
+
 type CameraSettings struct {
-	devId    *gui.Edit
-	deviceId int
-	c        chan os.Signal
-	mat      gocv.Mat
-	window   *gocv.Window
-	webcam   *gocv.VideoCapture
-	img      *gui.Image
+	app       *App
+	devId     *gui.Edit
+	camWidth  *gui.Edit // Requested resolution, 0 keeps the camera default
+	camHeight *gui.Edit
+	threshold *gui.Edit       // Brightness (0-255) of a spot
+	spots     *gui.CheckRadio // Mark bright spots on the view
+	fps       *gui.Label
+	deviceId  int
+	webcam    *gocv.VideoCapture // nil when no camera is open
+	mat       gocv.Mat
+	detector  *detect.Detector
+	view      videoView
+	failed    bool      // The camera could not be read, the error was shown
+	frames    int       // Frames read since the frame rate was updated
+	since     time.Time // When the frame rate was updated
 }
 
 func (s *CameraSettings) Initialize(a *App) {
-	s.deviceId = 0
-
-	// open webcam
-	var err error
-	s.webcam, err = gocv.VideoCaptureDevice(s.deviceId)
-	if err != nil {
-		fmt.Printf("error opening video capture device: %v\n", s.deviceId)
-		return
-	}
+	s.app = a
 
 	// prepare image matricies
 	s.mat = gocv.NewMat()
+	s.detector = detect.NewDetector(7)
 
 	a.AddFinalizer(func() {
 		// finalizer will close image and webcam
-		s.webcam.Close()
+		if s.webcam != nil {
+			s.webcam.Close()
+		}
 		s.mat.Close()
+		s.detector.Close()
+		s.view.dispose()
 	})
 
 	// Adds control panel after the header
@@ -51,39 +66,114 @@ func (s *CameraSettings) Initialize(a *App) {
 	l := gui.NewLabel("Camera Device ID (0 - ?)")
 	l.SetPosition(0, 0)
 	l.SetColor(darkTextColor)
-	a.Log().Info("Add label")
 	cpanel.Add(l)
 
 	s.devId = gui.NewEdit(50, "0")
 	s.devId.SetPosition(200, 0)
-	a.Log().Info("Add dev id")
 	cpanel.Add(s.devId)
 
+	rl := gui.NewLabel("Resolution (0 for default)")
+	rl.SetPosition(0, 30)
+	rl.SetColor(darkTextColor)
+	cpanel.Add(rl)
+
+	s.camWidth = gui.NewEdit(50, "0")
+	s.camWidth.SetPosition(200, 30)
+	cpanel.Add(s.camWidth)
+
+	s.camHeight = gui.NewEdit(50, "0")
+	s.camHeight.SetPosition(260, 30)
+	cpanel.Add(s.camHeight)
+
+	bOpen := gui.NewButton("Open Camera")
+	bOpen.SetPosition(330, 0)
+	bOpen.SetWidth(90)
+	bOpen.Subscribe(gui.OnClick, func(name string, ev interface{}) {
+		s.open()
+	})
+	cpanel.Add(bOpen)
+
+	s.spots = gui.NewCheckBox("Show bright spots above")
+	s.spots.SetPosition(0, 62)
+	s.spots.SetValue(true)
+	cpanel.Add(s.spots)
+
+	s.threshold = gui.NewEdit(50, "200")
+	s.threshold.SetPosition(200, 60)
+	cpanel.Add(s.threshold)
+
+	s.fps = gui.NewLabel("")
+	s.fps.SetPosition(330, 62)
+	s.fps.SetColor(darkTextColor)
+	cpanel.Add(s.fps)
+
 	a.GuiPanel().Add(cpanel)
+	s.open()
+}
 
-	// gocv logic
-	// channel to receive os signal
-	//s.c = make(chan os.Signal, 1)
-	//signal.Notify(s.c, os.Interrupt)
+// open (re)opens the camera with the device ID and resolution from the panel
+func (s *CameraSettings) open() {
+	id, err := strconv.Atoi(s.devId.Text())
+	if err != nil {
+		s.app.ed.Show(fmt.Sprintf("Invalid camera device ID %v", s.devId.Text()))
+		return
+	}
+	if s.webcam != nil {
+		s.webcam.Close()
+		s.webcam = nil
+	}
+
+	s.deviceId = id
+	webcam, err := gocv.VideoCaptureDevice(id)
+	if err != nil {
+		s.app.ed.Show(fmt.Sprintf("Unable to open camera %v: %v", id, err))
+		return
+	}
+	if w := ParseFloat32(s.camWidth.Text(), 0); w > 0 {
+		webcam.Set(gocv.VideoCaptureFrameWidth, float64(w))
+	}
+	if h := ParseFloat32(s.camHeight.Text(), 0); h > 0 {
+		webcam.Set(gocv.VideoCaptureFrameHeight, float64(h))
+	}
+	s.webcam = webcam
+	s.failed = false
+	s.frames = 0
+	s.since = time.Now()
 }
 
 func (s *CameraSettings) Render(a *App) {
-	if ok := s.webcam.Read(&s.mat); !ok {
-		fmt.Printf("cannot read device %d\n", s.deviceId)
+	if s.webcam == nil {
+		return
+	}
+	if ok := s.webcam.Read(&s.mat); !ok || s.mat.Empty() {
+		if !s.failed {
+			s.failed = true
+			a.ed.Show(fmt.Sprintf("Unable to read camera %d", s.deviceId))
+		}
 		return
 	}
 
-	// mat.ToImg, then type assert as image.RGBA
-	// https://stackoverflow.com/questions/31463756/convert-image-image-to-image-nrgba
+	if s.spots.Value() {
+		for _, spot := range s.detector.Spots(s.mat, ParseFloat32(s.threshold.Text(), 200), maxSpots) {
+			detect.Mark(&s.mat, spot.Point, spotColor)
+		}
+	}
+	detect.Crosshair(&s.mat, crosshairColor)
+
 	img, err := s.mat.ToImage()
 	if err != nil {
-		fmt.Errorf("Unable to read frame: %v\n", err)
+		a.Log().Error("Unable to read frame: %v", err)
 		return
 	}
+	if rgba, ok := img.(*image.RGBA); ok {
+		// below the control panel
+		s.view.show(a.GuiPanel(), rgba, 120)
+	}
 
-	if img, ok := img.(*image.RGBA); ok {
-		s.img = gui.NewImageFromRGBA(img)
-		a.GuiPanel().Add(s.img)
+	s.frames++
+	if d := time.Since(s.since); d >= time.Second {
+		s.fps.SetText(fmt.Sprintf("%.1f FPS at %v x %v", float64(s.frames)/d.Seconds(), s.mat.Cols(), s.mat.Rows()))
+		s.frames = 0
+		s.since = time.Now()
 	}
-	//col :=
 }
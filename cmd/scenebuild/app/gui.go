@@ -5,7 +5,6 @@ import (
 
 	"github.com/g3n/engine/gui"
 	"github.com/g3n/engine/math32"
-	"github.com/g3n/engine/window"
 )
 
 var darkTextColor = &math32.Color{.4, .4, .4}
@@ -21,14 +20,7 @@ func (app *App) buildGui() {
 	canvas.SetRenderable(false)
 	canvas.SetColor4(&gui.StyleDefault().Scroller.BgColor)
 	canvas.SetLayoutParams(&gui.DockLayoutParams{Edge: gui.DockCenter})
-	canvas.Subscribe(gui.OnMouseDown, func(name string, ev interface{}) {
-		mev := ev.(*window.MouseEvent)
-		//width, height := app.Window().Size()
-		w, h := canvas.Size()
-		x := mev.Xpos
-		y := mev.Ypos
-		app.Log().Debug("%v x %v in window %v x %v\n", x, y, w, h)
-	})
+	// mouse handlers are added by each IScreen, after setupScene clears them
 	app.Gui().Add(canvas)
 	app.SetPanel3D(canvas)
 
@@ -72,6 +64,14 @@ func (app *App) buildGui() {
 		header.Add(app.labelFPS)
 	}
 
+	bScene := gui.NewButton("Scene")
+	bScene.SetWidth(60)
+	bScene.SetHeight(30)
+	bScene.Subscribe(gui.OnClick, func(name string, ev interface{}) {
+		app.showScene()
+	})
+	header.Add(bScene)
+
 	bTestCam := gui.NewButton("Setup Camera")
 	bTestCam.SetWidth(90)
 	bTestCam.SetHeight(30)
@@ -85,6 +85,39 @@ func (app *App) buildGui() {
 	})
 	header.Add(bTestCam)
 
+	bMap := gui.NewButton("Map Fixture")
+	bMap.SetWidth(90)
+	bMap.SetHeight(30)
+	bMap.Subscribe(gui.OnClick, func(name string, ev interface{}) {
+		app.setupScene()
+		mf := MapFixture{}
+		mf.Initialize(app)
+		app.screen = &mf
+	})
+	header.Add(bMap)
+
+	bPreview := gui.NewButton("Preview")
+	bPreview.SetWidth(70)
+	bPreview.SetHeight(30)
+	bPreview.Subscribe(gui.OnClick, func(name string, ev interface{}) {
+		app.setupScene()
+		p := Preview{}
+		p.Initialize(app)
+		app.screen = &p
+	})
+	header.Add(bPreview)
+
+	bPower := gui.NewButton("Power")
+	bPower.SetWidth(60)
+	bPower.SetHeight(30)
+	bPower.Subscribe(gui.OnClick, func(name string, ev interface{}) {
+		app.setupScene()
+		p := Power{}
+		p.Initialize(app)
+		app.screen = &p
+	})
+	header.Add(bPower)
+
 	app.ed = NewErrorDialog(600, 100)
 	header.Add(app.ed)
 	/*
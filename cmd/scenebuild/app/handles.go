@@ -0,0 +1,292 @@
+package app
+
+import (
+	"github.com/g3n/engine/geometry"
+	"github.com/g3n/engine/graphic"
+	"github.com/g3n/engine/gui"
+	"github.com/g3n/engine/material"
+	"github.com/g3n/engine/math32"
+	"github.com/g3n/engine/window"
+	"github.com/tgreiser/cymapper/cmd/scenebuild/fixture"
+)
+
+// handle identifies the part of the selected fixture being dragged
+type handle int
+
+const (
+	handleNone handle = iota
+	handleMove
+	handleTopLeft
+	handleTopRight
+	handleBottomRight
+	handleBottomLeft
+	handleRotate
+)
+
+const (
+	ledRadius      = 3  // Radius of the LED circles, in scene units
+	handleRadius   = 6  // Radius of the corner handles, in scene units
+	handlePixels   = 8  // Minimum distance in screen pixels that picks a handle or LED
+	rotateDistance = 30 // Distance of the rotate handle above the fixture, in scene units
+)
+
+// drag is the state of the fixture when a mouse drag started
+type drag struct {
+	handle handle
+	start  *math32.Vector3 // scene position of the mouse down
+	xform  fixture.Affine  // fixture transformation at mouse down
+	anchor *math32.Vector3 // corner that stays still while scaling
+	corner *math32.Vector3 // corner being dragged
+	center *math32.Vector3 // center of the fixture, rotations turn about it
+	moving []int           // fixtures changed by the drag, the whole selection when moving
+	xforms []fixture.Affine
+	before []fixture.State // moving fixtures before the drag, for undo
+}
+
+// subscribeCanvas adds the mouse handlers for selecting and dragging fixtures
+func (s *SceneUI) subscribeCanvas() {
+	canvas := s.app.GuiPanel()
+	canvas.Subscribe(gui.OnMouseDown, func(name string, ev interface{}) {
+		mev := ev.(*window.MouseEvent)
+		if mev.Button != window.MouseButtonLeft || s.cpanel.ContainsPosition(mev.Xpos, mev.Ypos) {
+			return
+		}
+		s.onCanvasDown(s.canvasToScene(mev.Xpos, mev.Ypos), mev.Mods&window.ModControl != 0)
+		// keep receiving cursor events while dragging outside the canvas
+		s.app.Gui().SetMouseFocus(canvas)
+	})
+	canvas.Subscribe(gui.OnCursor, func(name string, ev interface{}) {
+		if s.drag == nil {
+			return
+		}
+		cev := ev.(*window.CursorEvent)
+		s.onCanvasDrag(s.canvasToScene(cev.Xpos, cev.Ypos))
+	})
+	canvas.Subscribe(gui.OnMouseUp, func(name string, ev interface{}) {
+		if s.drag != nil {
+			s.recordFixtures(s.drag.moving, s.drag.before)
+			s.drag = nil
+			s.app.Gui().SetMouseFocus(nil)
+		}
+	})
+	// cursor events carry no modifier keys, so track shift separately
+	s.app.Window().Subscribe(window.OnKeyDown, func(name string, ev interface{}) {
+		kev := ev.(*window.KeyEvent)
+		if kev.Keycode == window.KeyLeftShift || kev.Keycode == window.KeyRightShift {
+			s.shift = true
+		}
+	})
+	s.app.Window().Subscribe(window.OnKeyUp, func(name string, ev interface{}) {
+		kev := ev.(*window.KeyEvent)
+		if kev.Keycode == window.KeyLeftShift || kev.Keycode == window.KeyRightShift {
+			s.shift = false
+		}
+	})
+}
+
+// canvasToScene converts window coordinates into scene coordinates using the
+// planes and zoom of the orthographic camera
+func (s *SceneUI) canvasToScene(wx, wy float32) *math32.Vector3 {
+	canvas := s.app.GuiPanel()
+	cx, cy := canvas.ContentCoords(wx, wy)
+	width, height := canvas.ContentWidth(), canvas.ContentHeight()
+	cam := s.app.CameraOrtho()
+	left, right, top, bottom, _, _ := cam.Planes()
+	zoom := cam.Zoom()
+	pos := cam.Position()
+	x := (left + (right-left)*cx/width) / zoom
+	y := (top - (top-bottom)*cy/height) / zoom
+	return math32.NewVector3(pos.X+x, pos.Y+y, 0)
+}
+
+// scenePerPixel returns the size of one screen pixel in scene units
+func (s *SceneUI) scenePerPixel() float32 {
+	left, right, _, _, _, _ := s.app.CameraOrtho().Planes()
+	return (right - left) / s.app.CameraOrtho().Zoom() / s.app.GuiPanel().ContentWidth()
+}
+
+// corners returns the transformed corners and rotate handle of a fixture
+func corners(f *fixture.Fixture) map[handle]*math32.Vector3 {
+	tl, br := f.TransformedTopLeft(), f.TransformedBottomRight()
+	return map[handle]*math32.Vector3{
+		handleTopLeft:     math32.NewVector3(tl.X, tl.Y, 0),
+		handleTopRight:    math32.NewVector3(br.X, tl.Y, 0),
+		handleBottomRight: math32.NewVector3(br.X, br.Y, 0),
+		handleBottomLeft:  math32.NewVector3(tl.X, br.Y, 0),
+		handleRotate:      rotateHandle(f),
+	}
+}
+
+// opposite returns the corner that stays still while dragging h
+func opposite(h handle) handle {
+	switch h {
+	case handleTopLeft:
+		return handleBottomRight
+	case handleTopRight:
+		return handleBottomLeft
+	case handleBottomRight:
+		return handleTopLeft
+	case handleBottomLeft:
+		return handleTopRight
+	}
+	return handleNone
+}
+
+// rotateHandle returns the position of the rotate handle, above the top center
+func rotateHandle(f *fixture.Fixture) *math32.Vector3 {
+	c := f.TransformedCenter()
+	return math32.NewVector3(c.X, f.TransformedTopLeft().Y+rotateDistance, 0)
+}
+
+// pickHandle returns the handle of the selected fixture under p
+func (s *SceneUI) pickHandle(p *math32.Vector3) handle {
+	if s.selected < 0 {
+		return handleNone
+	}
+	reach := math32.Max(handleRadius, handlePixels*s.scenePerPixel())
+	for h, c := range corners(s.CurrentFixture()) {
+		if c.DistanceTo(p) <= reach {
+			return h
+		}
+	}
+	return handleNone
+}
+
+// pickFixture returns the fixture with an LED under p, preferring the
+// selected fixture and then the one drawn last. Clicks in the gaps of a
+// sparse fixture fall through to whatever is behind it.
+func (s *SceneUI) pickFixture(p *math32.Vector3) int {
+	reach := math32.Max(ledRadius, handlePixels*s.scenePerPixel())
+	if s.selected >= 0 && s.CurrentFixture().Near(p, reach) {
+		return s.selected
+	}
+	for iX := len(s.fixtures) - 1; iX >= 0; iX-- {
+		if s.fixtures[iX].Near(p, reach) {
+			return iX
+		}
+	}
+	return -1
+}
+
+// onCanvasDown selects the fixture or handle under p and starts dragging it.
+// Toggle adds or removes the fixture from the selection instead.
+func (s *SceneUI) onCanvasDown(p *math32.Vector3, toggle bool) {
+	if toggle {
+		s.toggleSelection(s.pickFixture(p))
+		return
+	}
+	h := s.pickHandle(p)
+	if h == handleNone {
+		picked := s.pickFixture(p)
+		if picked < 0 || !s.group[s.fixtures[picked]] {
+			s.group = nil
+		}
+		if picked != s.selected {
+			s.selectFixture(picked)
+		}
+		if picked < 0 {
+			return
+		}
+		h = handleMove
+	}
+
+	f := s.CurrentFixture()
+	c := corners(f)
+	s.drag = &drag{
+		handle: h,
+		start:  p,
+		xform:  f.Transformation(),
+		anchor: c[opposite(h)],
+		corner: c[h],
+		center: f.TransformedCenter(),
+		moving: []int{s.selected},
+	}
+	if h == handleMove {
+		s.drag.moving = s.selection()
+	}
+	for _, iX := range s.drag.moving {
+		s.drag.xforms = append(s.drag.xforms, s.fixtures[iX].Transformation())
+		s.drag.before = append(s.drag.before, s.fixtures[iX].State())
+	}
+}
+
+func (s *SceneUI) onCanvasDrag(p *math32.Vector3) {
+	d := s.drag
+	f := s.CurrentFixture()
+	switch d.handle {
+	case handleMove:
+		// snap the selected fixture and move the rest of the selection with it
+		f.SetTransformation(fixture.Translation(p.X-d.start.X, p.Y-d.start.Y).Multiply(d.xform))
+		dx, dy := s.snapper(d.moving).Offset(fixture.Edges(f))
+		move := fixture.Translation(p.X-d.start.X+dx, p.Y-d.start.Y+dy)
+		for iM, iX := range d.moving {
+			s.fixtures[iX].SetTransformation(move.Multiply(d.xforms[iM]))
+		}
+	case handleTopLeft, handleTopRight, handleBottomRight, handleBottomLeft:
+		dx, dy := s.snapper(d.moving).Offset([]float32{p.X}, []float32{p.Y})
+		p = math32.NewVector3(p.X+dx, p.Y+dy, 0)
+		sx, sy := float32(1), float32(1)
+		if d.corner.X != d.anchor.X {
+			sx = (p.X - d.anchor.X) / (d.corner.X - d.anchor.X)
+		}
+		if d.corner.Y != d.anchor.Y {
+			sy = (p.Y - d.anchor.Y) / (d.corner.Y - d.anchor.Y)
+		}
+		if s.shift {
+			// keep the aspect ratio, following the axis dragged furthest
+			m := math32.Max(math32.Abs(sx), math32.Abs(sy))
+			sx, sy = withSign(m, sx), withSign(m, sy)
+		}
+		f.SetTransformation(fixture.Scaling(sx, sy, d.anchor).Multiply(d.xform))
+	case handleRotate:
+		angle := math32.Atan2(p.Y-d.center.Y, p.X-d.center.X) -
+			math32.Atan2(d.start.Y-d.center.Y, d.start.X-d.center.X)
+		if s.shift {
+			// snap to 15 degree steps
+			step := math32.DegToRad(15)
+			angle = math32.Round(angle/step) * step
+		}
+		f.SetTransformation(fixture.Rotation(angle, d.center).Multiply(d.xform))
+	}
+	s.SetCorners()
+	s.Draw()
+}
+
+// withSign returns magnitude m with the sign of v
+func withSign(m, v float32) float32 {
+	if v < 0 {
+		return -m
+	}
+	return m
+}
+
+// selectFixture makes fixture iX current in the drop down and edit fields
+func (s *SceneUI) selectFixture(iX int) {
+	s.selected = iX
+	s.list.SelectPos(iX)
+	if iX < 0 {
+		s.tlx.SetText("")
+		s.tly.SetText("")
+		s.brx.SetText("")
+		s.bry.SetText("")
+		s.name.SetText("")
+		s.group = nil
+	}
+	s.SetCorners()
+	s.Draw()
+}
+
+// DrawHandles draws the corner and rotate handles of the selected fixture
+func (s *SceneUI) DrawHandles(f *fixture.Fixture) {
+	rmat := material.NewStandard(math32.NewColor("red"))
+	rmat.SetSide(material.SideFront)
+	rmat.SetWireframe(true)
+	rmat.SetLineWidth(1)
+
+	c := corners(f)
+	for _, h := range []handle{handleTopLeft, handleTopRight, handleBottomRight, handleBottomLeft, handleRotate} {
+		circle := graphic.NewMesh(geometry.NewCircle(handleRadius, 16), rmat)
+		circle.SetPositionVec(c[h])
+		s.app.Scene().Add(circle)
+	}
+}
@@ -0,0 +1,284 @@
+package app
+
+import (
+	"github.com/g3n/engine/gui"
+	"github.com/g3n/engine/window"
+	"github.com/tgreiser/cymapper/cmd/scenebuild/fixture"
+	"github.com/tgreiser/cymapper/cmd/scenebuild/history"
+)
+
+// Command is a change to the scene that can be undone
+type Command interface {
+	Do(s *SceneUI)
+	Undo(s *SceneUI)
+}
+
+// merger is implemented by commands that absorb the next command, so typing
+// a number into an edit field is undone in one step instead of per key
+type merger interface {
+	merge(next Command) bool
+}
+
+// sceneCommand applies a command to the scene for the history
+type sceneCommand struct {
+	Command
+	s *SceneUI
+}
+
+func (c sceneCommand) Do() {
+	c.Command.Do(c.s)
+}
+
+func (c sceneCommand) Undo() {
+	c.Command.Undo(c.s)
+}
+
+func (c sceneCommand) Merge(next history.Command) bool {
+	m, ok := c.Command.(merger)
+	n, nok := next.(sceneCommand)
+	return ok && nok && m.merge(n.Command)
+}
+
+// record adds a command that has already been applied to the history
+func (s *SceneUI) record(c Command) {
+	s.history.Record(sceneCommand{c, s})
+}
+
+// execute applies a command and records it for undo
+func (s *SceneUI) execute(c Command) {
+	c.Do(s)
+	s.record(c)
+	s.refresh()
+}
+
+func (s *SceneUI) undo() {
+	if s.history.Undo() {
+		s.refresh()
+	}
+}
+
+func (s *SceneUI) redo() {
+	if s.history.Redo() {
+		s.refresh()
+	}
+}
+
+// recordFixture records a change to fixture iX made since before was taken
+func (s *SceneUI) recordFixture(iX int, before fixture.State, typed bool) {
+	after := s.fixtures[iX].State()
+	if !before.Equal(after) {
+		s.record(&changeFixture{index: iX, before: before, after: after, typed: typed})
+	}
+}
+
+// recordFixtures records changes to several fixtures as one step
+func (s *SceneUI) recordFixtures(indices []int, before []fixture.State) {
+	var b batch
+	for iB, iX := range indices {
+		after := s.fixtures[iX].State()
+		if !before[iB].Equal(after) {
+			b = append(b, &changeFixture{index: iX, before: before[iB], after: after})
+		}
+	}
+	switch len(b) {
+	case 0:
+	case 1:
+		s.record(b[0])
+	default:
+		s.record(b)
+	}
+}
+
+// refresh rebuilds the fixture list and edit fields to match the scene after
+// a command
+func (s *SceneUI) refresh() {
+	// Removes and then creates new fixture panel because it's a pain to modify
+	s.cpanel.Remove(s.list)
+	list := s.newFixturesDropDown(s.cpanel)
+	for _, f := range s.fixtures {
+		list.Add(gui.NewImageLabel(f.Name()))
+	}
+	if s.selected >= len(s.fixtures) {
+		s.selected = len(s.fixtures) - 1
+	}
+	// keep the selection, less any fixtures that were removed
+	group := s.group
+	s.group = nil
+	for _, f := range s.fixtures {
+		if group[f] {
+			if s.group == nil {
+				s.group = map[*fixture.Fixture]bool{}
+			}
+			s.group[f] = true
+		}
+	}
+	s.width.SetText(FormatFloat32(s.sceneWidth))
+	s.height.SetText(FormatFloat32(s.sceneHeight))
+	s.selectFixture(s.selected)
+}
+
+// subscribeHistory binds Ctrl-Z to undo, and Ctrl-Y or Ctrl-Shift-Z to redo
+func (s *SceneUI) subscribeHistory() {
+	s.app.Window().Subscribe(window.OnKeyDown, func(name string, ev interface{}) {
+		kev := ev.(*window.KeyEvent)
+		if kev.Keycode == window.KeyZ && kev.Mods == window.ModControl {
+			s.undo()
+		}
+		if (kev.Keycode == window.KeyY && kev.Mods == window.ModControl) ||
+			(kev.Keycode == window.KeyZ && kev.Mods == window.ModControl|window.ModShift) {
+			s.redo()
+		}
+	})
+}
+
+// batch is several commands undone and redone as one
+type batch []Command
+
+func (b batch) Do(s *SceneUI) {
+	for _, c := range b {
+		c.Do(s)
+	}
+}
+
+func (b batch) Undo(s *SceneUI) {
+	for iC := len(b) - 1; iC >= 0; iC-- {
+		b[iC].Undo(s)
+	}
+}
+
+// addFixture inserts a fixture into the scene at index
+type addFixture struct {
+	fixture *fixture.Fixture
+	index   int
+}
+
+func (c *addFixture) Do(s *SceneUI) {
+	s.fixtures = append(s.fixtures, nil)
+	copy(s.fixtures[c.index+1:], s.fixtures[c.index:])
+	s.fixtures[c.index] = c.fixture
+	s.selected = c.index
+}
+
+func (c *addFixture) Undo(s *SceneUI) {
+	s.fixtures = append(s.fixtures[:c.index], s.fixtures[c.index+1:]...)
+	s.selected = -1
+}
+
+// removeFixture takes the fixture at index out of the scene
+type removeFixture struct {
+	addFixture
+}
+
+func (c *removeFixture) Do(s *SceneUI) {
+	c.fixture = s.fixtures[c.index]
+	c.addFixture.Undo(s)
+}
+
+func (c *removeFixture) Undo(s *SceneUI) {
+	c.addFixture.Do(s)
+}
+
+// clearFixtures removes every fixture from the scene
+type clearFixtures struct {
+	fixtures []*fixture.Fixture
+	selected int
+}
+
+func (c *clearFixtures) Do(s *SceneUI) {
+	c.fixtures, c.selected = s.fixtures, s.selected
+	s.fixtures = nil
+	s.selected = -1
+}
+
+func (c *clearFixtures) Undo(s *SceneUI) {
+	s.fixtures, s.selected = c.fixtures, c.selected
+}
+
+// moveFixture changes the position of a fixture in the list, and with it
+// the address order of the scene
+type moveFixture struct {
+	from int
+	to   int
+}
+
+func (c *moveFixture) Do(s *SceneUI) {
+	s.moveFixture(c.from, c.to)
+}
+
+func (c *moveFixture) Undo(s *SceneUI) {
+	s.moveFixture(c.to, c.from)
+}
+
+func (s *SceneUI) moveFixture(from, to int) {
+	f := s.fixtures[from]
+	s.fixtures = append(s.fixtures[:from], s.fixtures[from+1:]...)
+	s.fixtures = append(s.fixtures[:to], append([]*fixture.Fixture{f}, s.fixtures[to:]...)...)
+	s.selected = to
+}
+
+// renameFixture changes the display name of the fixture at index
+type renameFixture struct {
+	index  int
+	before string
+	after  string
+}
+
+func (c *renameFixture) Do(s *SceneUI) {
+	s.fixtures[c.index].SetName(c.after)
+	s.selected = c.index
+}
+
+func (c *renameFixture) Undo(s *SceneUI) {
+	s.fixtures[c.index].SetName(c.before)
+	s.selected = c.index
+}
+
+// changeFixture moves, scales, rotates or flips the fixture at index
+type changeFixture struct {
+	index  int
+	before fixture.State
+	after  fixture.State
+	typed  bool // made by typing in the corner fields
+}
+
+func (c *changeFixture) Do(s *SceneUI) {
+	s.fixtures[c.index].Restore(c.after)
+	s.selected = c.index
+}
+
+func (c *changeFixture) Undo(s *SceneUI) {
+	s.fixtures[c.index].Restore(c.before)
+	s.selected = c.index
+}
+
+func (c *changeFixture) merge(next Command) bool {
+	n, ok := next.(*changeFixture)
+	if !ok || !c.typed || !n.typed || n.index != c.index {
+		return false
+	}
+	c.after = n.after
+	return true
+}
+
+// resizeCanvas changes the scene width and height
+type resizeCanvas struct {
+	before [2]float32
+	after  [2]float32
+}
+
+func (c *resizeCanvas) Do(s *SceneUI) {
+	s.sceneWidth, s.sceneHeight = c.after[0], c.after[1]
+}
+
+func (c *resizeCanvas) Undo(s *SceneUI) {
+	s.sceneWidth, s.sceneHeight = c.before[0], c.before[1]
+}
+
+func (c *resizeCanvas) merge(next Command) bool {
+	n, ok := next.(*resizeCanvas)
+	if !ok {
+		return false
+	}
+	c.after = n.after
+	return true
+}
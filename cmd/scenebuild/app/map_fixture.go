@@ -0,0 +1,255 @@
+package app
+
+import (
+	"encoding/csv"
+	"fmt"
+	"image"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/g3n/engine/gui"
+	"github.com/g3n/engine/math32"
+	"github.com/tgreiser/cymapper/cmd/scenebuild/fixture"
+	"github.com/tgreiser/cymapper/layout"
+	"github.com/tgreiser/cymapper/mapper"
+	"github.com/tgreiser/cymapper/output"
+	"gocv.io/x/gocv"
+)
+
+// progressWidth is the width of the progress bar in pixels
+const progressWidth = 300
+
+// MapFixture runs the cameramap sequence in the background and adds the
+// finished map to the scene as a new fixture
+type MapFixture struct {
+	app        *App
+	port       *gui.Edit
+	pins       *gui.Edit
+	leds       *gui.Edit
+	brightness *gui.Edit
+	layout     *gui.Edit // Wiring of the LEDs, blank for Pins strips of LEDs per pin
+	devId      *gui.Edit
+	delay      *gui.Edit
+	file       *gui.Edit
+	status     *gui.Label
+	bar        *gui.Panel // Filled part of the progress bar
+	view       videoView
+	out        output.Output
+	webcam     *gocv.VideoCapture
+	progress   chan mapper.Progress
+	done       chan mapResult
+	cancel     chan struct{} // Closed to stop mapping
+	running    bool
+	stopped    bool // Stopped by the user, the partial map is thrown away
+}
+
+// mapResult is the outcome of a mapping run
+type mapResult struct {
+	pts []image.Point
+	err error
+}
+
+func (m *MapFixture) Initialize(a *App) {
+	m.app = a
+
+	a.AddFinalizer(func() {
+		// wait for the capture to let go of the camera and port
+		if m.running {
+			if !m.stopped {
+				close(m.cancel)
+			}
+			<-m.done
+			m.closeDevices()
+		}
+		m.view.dispose()
+	})
+
+	// Adds control panel after the header
+	cpanel := gui.NewPanel(800, 120)
+	cpanel.SetBorders(0, 0, 1, 0)
+	cpanel.SetPaddings(4, 4, 4, 4)
+	cpanel.SetColor(math32.NewColorHex(0xffca6e))
+	cpanel.SetLayoutParams(&gui.DockLayoutParams{Edge: gui.DockTop})
+
+	m.port = addLabelledEdit(cpanel, "Output", "COM8", 0, 0, 100)
+	m.pins = addLabelledEdit(cpanel, "Pins", "8", 170, 0, 40)
+	m.leds = addLabelledEdit(cpanel, "LEDs per pin", "460", 270, 0, 50)
+	m.brightness = addLabelledEdit(cpanel, "Brightness", "64", 430, 0, 40)
+	m.layout = addLabelledEdit(cpanel, "Layout", "", 560, 0, 180)
+	m.devId = addLabelledEdit(cpanel, "Camera", "0", 0, 30, 40)
+	m.delay = addLabelledEdit(cpanel, "Delay ms", "1000", 170, 30, 50)
+	m.file = addLabelledEdit(cpanel, "Save as", "../../fixtures/map.tsv", 300, 30, 200)
+
+	bStart := gui.NewButton("Start")
+	bStart.SetPosition(0, 60)
+	bStart.SetWidth(60)
+	bStart.Subscribe(gui.OnClick, func(name string, ev interface{}) {
+		m.start()
+	})
+	cpanel.Add(bStart)
+
+	bStop := gui.NewButton("Stop")
+	bStop.SetPosition(66, 60)
+	bStop.SetWidth(60)
+	bStop.Subscribe(gui.OnClick, func(name string, ev interface{}) {
+		if m.running && !m.stopped {
+			m.stopped = true
+			close(m.cancel)
+			m.status.SetText("Stopping")
+		}
+	})
+	cpanel.Add(bStop)
+
+	track := gui.NewPanel(progressWidth, 14)
+	track.SetPosition(140, 66)
+	track.SetColor(math32.NewColorHex(0xffffff))
+	cpanel.Add(track)
+	m.bar = gui.NewPanel(0, 14)
+	m.bar.SetColor(math32.NewColorHex(0x956eff))
+	track.Add(m.bar)
+
+	m.status = gui.NewLabel("Connect the LEDs and point the camera at them")
+	m.status.SetPosition(450, 64)
+	m.status.SetColor(darkTextColor)
+	cpanel.Add(m.status)
+
+	a.GuiPanel().Add(cpanel)
+}
+
+// start opens the port and camera and starts lighting LEDs in the background
+func (m *MapFixture) start() {
+	if m.running {
+		return
+	}
+	cfg := mapper.Config{StartPin: 1, Radius: 7}
+	var delayMs, deviceId int
+	for _, f := range []struct {
+		name string
+		ed   *gui.Edit
+		v    *int
+	}{
+		{"pins", m.pins, &cfg.Pins},
+		{"LEDs per pin", m.leds, &cfg.Leds},
+		{"brightness", m.brightness, &cfg.Brightness},
+		{"camera", m.devId, &deviceId},
+		{"delay", m.delay, &delayMs},
+	} {
+		v, err := strconv.Atoi(f.ed.Text())
+		if err != nil || v < 0 {
+			m.app.ed.Show(fmt.Sprintf("Invalid %v %v", f.name, f.ed.Text()))
+			return
+		}
+		*f.v = v
+	}
+	cfg.Delay = time.Duration(delayMs) * time.Millisecond
+	if m.layout.Text() != "" {
+		lay, err := layout.Parse(m.layout.Text())
+		if err != nil {
+			m.app.ed.Show(fmt.Sprintf("Bad layout %v: %v", m.layout.Text(), err))
+			return
+		}
+		cfg.Layout = lay
+	}
+
+	out, err := output.Open(m.port.Text())
+	if err != nil {
+		m.app.ed.Show(fmt.Sprintf("When connecting to output: %v: %v", m.port.Text(), err))
+		return
+	}
+	webcam, err := gocv.VideoCaptureDevice(deviceId)
+	if err != nil {
+		out.Close()
+		m.app.ed.Show(fmt.Sprintf("Unable to open camera %v: %v", deviceId, err))
+		return
+	}
+
+	m.out, m.webcam = out, webcam
+	m.progress = make(chan mapper.Progress, 1)
+	m.done = make(chan mapResult, 1)
+	m.cancel = make(chan struct{})
+	m.running, m.stopped = true, false
+	m.bar.SetWidth(0)
+	m.status.SetText("Mapping")
+
+	go func() {
+		pts, err := mapper.Run(cfg, out, webcam, m.cancel, func(p mapper.Progress) {
+			// the gui only needs the latest frame
+			select {
+			case <-m.progress:
+			default:
+			}
+			m.progress <- p
+		})
+		m.done <- mapResult{pts, err}
+	}()
+}
+
+func (m *MapFixture) Render(a *App) {
+	if !m.running {
+		return
+	}
+	select {
+	case p := <-m.progress:
+		m.bar.SetWidth(progressWidth * float32(p.Done) / float32(p.Total))
+		if !m.stopped {
+			m.status.SetText(fmt.Sprintf("LED %v of %v at %v x %v", p.Done, p.Total, p.Point.X, p.Point.Y))
+		}
+		if p.Frame != nil {
+			// below the control panel
+			m.view.show(a.GuiPanel(), p.Frame, 120)
+		}
+	default:
+	}
+	select {
+	case r := <-m.done:
+		m.finish(r)
+	default:
+	}
+}
+
+// finish saves the map and adds it to the scene
+func (m *MapFixture) finish(r mapResult) {
+	m.closeDevices()
+	m.running = false
+	if r.err != nil {
+		m.app.ed.Show(fmt.Sprintf("Mapping stopped after %v LEDs: %v", len(r.pts), r.err))
+		return
+	}
+	if m.stopped {
+		m.status.SetText(fmt.Sprintf("Stopped after %v LEDs", len(r.pts)))
+		return
+	}
+
+	path := m.file.Text()
+	if err := writeMap(path, r.pts); err != nil {
+		m.app.ed.Show(fmt.Sprintf("Unable to create %v: %v", path, err))
+		return
+	}
+	m.app.Log().Info("Mapped %v LEDs to %v", len(r.pts), path)
+
+	// added like any other map file, see fixture.NewFixture
+	m.app.showScene()
+	m.app.scene.execute(&addFixture{fixture: fixture.NewFixture(path), index: len(m.app.scene.fixtures)})
+}
+
+func (m *MapFixture) closeDevices() {
+	m.webcam.Close()
+	m.out.Close()
+}
+
+// writeMap saves camera points in the cameramap TSV format
+func writeMap(path string, pts []image.Point) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	w := csv.NewWriter(file)
+	w.Comma = '\t'
+	for _, pt := range pts {
+		w.Write([]string{strconv.Itoa(pt.X), strconv.Itoa(pt.Y)})
+	}
+	w.Flush()
+	return w.Error()
+}
@@ -0,0 +1,116 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/g3n/engine/gui"
+	"github.com/g3n/engine/math32"
+	"github.com/tgreiser/cymapper/layout"
+	"github.com/tgreiser/cymapper/power"
+)
+
+// colour of loads over their limit
+var overColor = math32.NewColorHex(0xcc0000)
+
+// Power estimates the current each fixture and pin of the scene draws, to
+// check the supplies and power injection before the rig is switched on
+type Power struct {
+	app         *App
+	volts       *gui.Edit
+	milliamps   *gui.Edit
+	brightness  *gui.Edit
+	typical     *gui.Edit
+	pinAmps     *gui.Edit
+	fixtureAmps *gui.Edit
+	supplyAmps  *gui.Edit
+	layout      *gui.Edit
+	status      *gui.Label
+	list        *gui.List
+}
+
+func (p *Power) Initialize(a *App) {
+	p.app = a
+
+	// Adds control panel after the header
+	cpanel := gui.NewPanel(800, 120)
+	cpanel.SetBorders(0, 0, 1, 0)
+	cpanel.SetPaddings(4, 4, 4, 4)
+	cpanel.SetColor(math32.NewColorHex(0xffca6e))
+	cpanel.SetLayoutParams(&gui.DockLayoutParams{Edge: gui.DockTop})
+
+	p.volts = addLabelledEdit(cpanel, "Volts", "5", 0, 0, 40)
+	p.milliamps = addLabelledEdit(cpanel, "mA per channel", "20", 100, 0, 40)
+	p.brightness = addLabelledEdit(cpanel, "Brightness %", "100", 260, 0, 40)
+	p.typical = addLabelledEdit(cpanel, "Typical %", "30", 410, 0, 40)
+	p.pinAmps = addLabelledEdit(cpanel, "Pin A", "0", 0, 30, 40)
+	p.fixtureAmps = addLabelledEdit(cpanel, "Fixture A", "0", 100, 30, 40)
+	p.supplyAmps = addLabelledEdit(cpanel, "Supply A", "0", 230, 30, 40)
+	p.layout = addLabelledEdit(cpanel, "Layout", "8x460", 0, 60, 300)
+
+	bCalc := gui.NewButton("Calculate")
+	bCalc.SetPosition(380, 58)
+	bCalc.SetWidth(90)
+	bCalc.Subscribe(gui.OnClick, func(name string, ev interface{}) {
+		p.calculate()
+	})
+	cpanel.Add(bCalc)
+
+	p.status = gui.NewLabel("Limits of 0 are not checked")
+	p.status.SetPosition(0, 92)
+	p.status.SetColor(darkTextColor)
+	cpanel.Add(p.status)
+
+	a.GuiPanel().Add(cpanel)
+
+	p.list = gui.NewVList(800, 400)
+	p.list.SetLayoutParams(&gui.DockLayoutParams{Edge: gui.DockCenter})
+	a.GuiPanel().Add(p.list)
+
+	p.calculate()
+}
+
+// calculate estimates the scene with the settings in the panel and lists
+// every fixture and pin
+func (p *Power) calculate() {
+	lay, err := layout.Parse(p.layout.Text())
+	if err != nil {
+		p.app.ed.Show(fmt.Sprintf("Bad layout %v: %v", p.layout.Text(), err))
+		return
+	}
+	fixtures := []power.Fixture{}
+	for _, f := range p.app.scene.fixtures {
+		fixtures = append(fixtures, power.Fixture{Name: f.Name(), Pixels: f.Length()})
+	}
+	r := power.Estimate(fixtures, lay, power.Config{
+		Volts:            float64(ParseFloat32(p.volts.Text(), 5)),
+		ChannelMilliamps: float64(ParseFloat32(p.milliamps.Text(), 20)),
+		IdleMilliamps:    1,
+		Brightness:       float64(ParseFloat32(p.brightness.Text(), 100)) / 100,
+		Typical:          float64(ParseFloat32(p.typical.Text(), 30)) / 100,
+		PinAmps:          float64(ParseFloat32(p.pinAmps.Text(), 0)),
+		FixtureAmps:      float64(ParseFloat32(p.fixtureAmps.Text(), 0)),
+		SupplyAmps:       float64(ParseFloat32(p.supplyAmps.Text(), 0)),
+	})
+
+	p.list.Clear()
+	for _, loads := range [][]power.Load{r.Fixtures, r.Pins, {r.Total}} {
+		for _, l := range loads {
+			label := gui.NewLabel(fmt.Sprintf("%v (%.0f W)", l, l.Worst*r.Volts))
+			label.SetColor(darkTextColor)
+			if l.Over() {
+				label.SetColor(overColor)
+			}
+			p.list.Add(label)
+		}
+	}
+
+	status := fmt.Sprintf("%v over the limit", len(r.Over()))
+	if r.Unwired > 0 {
+		status += fmt.Sprintf(", %v LEDs are past the last pin of the layout", r.Unwired)
+	}
+	p.status.SetText(status)
+}
+
+// Render has nothing to animate, the report changes on Calculate
+func (p *Power) Render(a *App) {
+}
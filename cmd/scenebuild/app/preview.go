@@ -0,0 +1,328 @@
+package app
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/g3n/engine/geometry"
+	"github.com/g3n/engine/graphic"
+	"github.com/g3n/engine/gui"
+	"github.com/g3n/engine/material"
+	"github.com/g3n/engine/math32"
+	"github.com/tgreiser/cymapper/cmd/scenebuild/fixture"
+	"github.com/tgreiser/cymapper/correct"
+	"github.com/tgreiser/cymapper/effects"
+	"github.com/tgreiser/cymapper/layout"
+	"github.com/tgreiser/cymapper/output"
+)
+
+// Preview plays a test pattern over the scene, on screen and on the LEDs, to
+// check the mapping against the real rig
+type Preview struct {
+	app      *App
+	patterns *gui.DropDown
+	speed    *gui.Edit
+	image    *gui.Edit
+	port     *gui.Edit
+	fps      *gui.Edit
+	leds     *gui.Edit
+	layout   *gui.Edit // Wiring of the LEDs, blank for Total LEDs of RGB
+	gamma    *gui.Edit
+	bright   *gui.Edit
+	white    *gui.Edit // White point of every fixture, or one per fixture
+	maxAmps  *gui.Edit
+	status   *gui.Label
+	pattern  effects.Pattern
+	pts      []effects.Point      // Scene LEDs in address order
+	mats     []*material.Standard // Material of each LED drawn on screen
+	frame    []byte
+	started  time.Time
+	sent     time.Time     // When the last frame was sent to the LEDs
+	out      output.Output // nil when not streaming
+	frames   chan []byte   // Frames waiting for the output
+	errs     chan error    // Output errors
+	done     chan struct{} // Closed when the output goroutine has stopped writing
+}
+
+// preview pattern names, in drop down order
+var previewPatterns = []string{"Gradient sweep", "Moving bar", "Vertical bar", "Image"}
+
+func (p *Preview) Initialize(a *App) {
+	p.app = a
+	p.started = time.Now()
+
+	a.AddFinalizer(func() {
+		p.stopOutput()
+	})
+
+	// Adds control panel after the header
+	cpanel := gui.NewPanel(800, 150)
+	cpanel.SetBorders(0, 0, 1, 0)
+	cpanel.SetPaddings(4, 4, 4, 4)
+	cpanel.SetColor(math32.NewColorHex(0xffca6e))
+	cpanel.SetLayoutParams(&gui.DockLayoutParams{Edge: gui.DockTop})
+
+	l := gui.NewLabel("Play a pattern over the scene, then send it to the LEDs to check the mapping.")
+	l.SetPosition(0, 0)
+	l.SetColor(darkTextColor)
+	cpanel.Add(l)
+
+	p.patterns = gui.NewDropDown(140, gui.NewImageLabel(""))
+	p.patterns.SetPosition(0, 22)
+	for _, name := range previewPatterns {
+		p.patterns.Add(gui.NewImageLabel(name))
+	}
+	p.patterns.SelectPos(0)
+	p.patterns.Subscribe(gui.OnChange, func(name string, ev interface{}) {
+		p.setPattern()
+	})
+	cpanel.Add(p.patterns)
+
+	p.speed = addLabelledEdit(cpanel, "Speed", "0.25", 150, 24, 40)
+	p.image = addLabelledEdit(cpanel, "Image", "", 250, 24, 200)
+	p.speed.Subscribe(gui.OnChange, func(name string, ev interface{}) {
+		p.setPattern()
+	})
+	p.image.Subscribe(gui.OnChange, func(name string, ev interface{}) {
+		p.setPattern()
+	})
+
+	p.port = addLabelledEdit(cpanel, "Output", "COM8", 0, 56, 100)
+	p.fps = addLabelledEdit(cpanel, "FPS", "30", 160, 56, 40)
+	p.leds = addLabelledEdit(cpanel, "Total LEDs", "0", 240, 56, 50)
+	p.layout = addLabelledEdit(cpanel, "Layout", "", 0, 88, 150)
+	p.gamma = addLabelledEdit(cpanel, "Gamma", "2.2", 220, 88, 40)
+	p.bright = addLabelledEdit(cpanel, "Brightness", "1", 320, 88, 40)
+	p.white = addLabelledEdit(cpanel, "White", "", 440, 88, 80)
+	p.maxAmps = addLabelledEdit(cpanel, "Max A", "0", 580, 88, 40)
+
+	bStart := gui.NewButton("Start Output")
+	bStart.SetPosition(380, 54)
+	bStart.SetWidth(90)
+	bStart.Subscribe(gui.OnClick, func(name string, ev interface{}) {
+		p.startOutput()
+	})
+	cpanel.Add(bStart)
+
+	bStop := gui.NewButton("Stop Output")
+	bStop.SetPosition(476, 54)
+	bStop.SetWidth(90)
+	bStop.Subscribe(gui.OnClick, func(name string, ev interface{}) {
+		p.stopOutput()
+		p.status.SetText("Output stopped")
+	})
+	cpanel.Add(bStop)
+
+	p.status = gui.NewLabel("")
+	p.status.SetPosition(0, 120)
+	p.status.SetColor(darkTextColor)
+	cpanel.Add(p.status)
+
+	a.GuiPanel().Add(cpanel)
+
+	p.setPattern()
+	p.drawScene()
+}
+
+// addLabelledEdit adds an edit field with a label in front of it
+func addLabelledEdit(cpanel *gui.Panel, label, text string, x, y, width float32) *gui.Edit {
+	l := gui.NewLabel(label)
+	l.SetPosition(x, y+2)
+	l.SetColor(darkTextColor)
+	cpanel.Add(l)
+
+	ed := gui.NewEdit(int(width), text)
+	ed.SetText(text)
+	ed.SetPosition(x+l.Width()+6, y)
+	cpanel.Add(ed)
+	return ed
+}
+
+// setPattern builds the pattern chosen in the panel
+func (p *Preview) setPattern() {
+	speed := float64(ParseFloat32(p.speed.Text(), 0.25))
+	white := color.RGBA{255, 255, 255, 255}
+	switch p.patterns.SelectedPos() {
+	case 1:
+		p.pattern = effects.Bar{Width: 0.05, Speed: speed, Color: white}
+	case 2:
+		p.pattern = effects.Bar{Width: 0.05, Speed: speed, Color: white, Vertical: true}
+	case 3:
+		img, err := loadImage(p.image.Text())
+		if err != nil {
+			p.status.SetText(fmt.Sprintf("Unable to load image: %v", err))
+			return
+		}
+		p.pattern = effects.NewImage(img)
+	default:
+		p.pattern = effects.Sweep{Speed: speed}
+	}
+}
+
+func loadImage(path string) (image.Image, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	img, _, err := image.Decode(file)
+	return img, err
+}
+
+// drawScene draws every LED of the scene once, Render only changes colours
+func (p *Preview) drawScene() {
+	s := p.app.scene
+	p.app.Scene().Add(p.app.ambLight)
+	p.app.Scene().Add(p.app.CameraOrtho().GetCamera())
+	vx, vy := s.sceneWidth/2, s.sceneHeight/2
+	p.app.CameraOrtho().SetPosition(vx, vy, 99)
+	p.app.CameraOrtho().LookAt(&math32.Vector3{vx, vy, 0})
+	if p.app.zoom != nil {
+		p.app.CameraOrtho().SetZoom(p.app.zoom.Value() / 100)
+	}
+
+	p.pts = nil
+	p.mats = nil
+	for _, v := range fixture.NewScene(s.fixtures).Points() {
+		// scene Y points up, patterns run down like an image
+		p.pts = append(p.pts, effects.Point{
+			X: float64(v.X / s.sceneWidth),
+			Y: float64(1 - v.Y/s.sceneHeight),
+		})
+		mat := material.NewStandard(math32.NewColor("black"))
+		mat.SetSide(material.SideDouble)
+		circle := graphic.NewMesh(geometry.NewCircle(3, 16), mat)
+		circle.SetPositionVec(v)
+		p.app.Scene().Add(circle)
+		p.mats = append(p.mats, mat)
+	}
+	p.status.SetText(fmt.Sprintf("%v LEDs in the scene", len(p.pts)))
+
+	if err := p.app.Renderer().AddDefaultShaders(); err != nil {
+		panic(err)
+	}
+	p.app.Renderer().SetScene(p.app.Scene())
+}
+
+func (p *Preview) Render(a *App) {
+	if p.pattern == nil {
+		return
+	}
+	p.frame = effects.Frame(p.pattern, p.pts, time.Since(p.started).Seconds(), p.frame)
+	for iM, mat := range p.mats {
+		mat.SetColor(&math32.Color{
+			R: float32(p.frame[iM*3]) / 255,
+			G: float32(p.frame[iM*3+1]) / 255,
+			B: float32(p.frame[iM*3+2]) / 255,
+		})
+	}
+
+	if p.out == nil {
+		return
+	}
+	select {
+	case err := <-p.errs:
+		p.stopOutput()
+		a.ed.Show(fmt.Sprintf("Output error: %v", err))
+		return
+	default:
+	}
+	fps := ParseFloat32(p.fps.Text(), 30)
+	if fps <= 0 || time.Since(p.sent) < time.Duration(float32(time.Second)/fps) {
+		return
+	}
+	p.sent = time.Now()
+	frame := make([]byte, len(p.frame))
+	copy(frame, p.frame)
+	// drop the frame when the output is still busy with the last one
+	select {
+	case p.frames <- frame:
+	default:
+	}
+}
+
+// startOutput opens the LED output and starts sending frames in the background
+func (p *Preview) startOutput() {
+	if p.out != nil {
+		return
+	}
+	total, err := strconv.Atoi(p.leds.Text())
+	if err != nil || total < 0 {
+		p.app.ed.Show(fmt.Sprintf("Invalid total LEDs %v", p.leds.Text()))
+		return
+	}
+	if total < len(p.pts) {
+		total = len(p.pts)
+	}
+	lay := layout.Uniform(1, total)
+	if p.layout.Text() != "" {
+		if lay, err = layout.Parse(p.layout.Text()); err != nil {
+			p.app.ed.Show(fmt.Sprintf("Bad layout %v: %v", p.layout.Text(), err))
+			return
+		}
+	}
+	pipe, err := p.pipeline()
+	if err != nil {
+		p.app.ed.Show(fmt.Sprintf("Bad white point %v: %v", p.white.Text(), err))
+		return
+	}
+	port, err := output.Open(p.port.Text())
+	if err != nil {
+		p.app.ed.Show(fmt.Sprintf("When connecting to output: %v: %v", p.port.Text(), err))
+		return
+	}
+	// the layout sends every LED it describes, in the strips' colour order
+	// and direction
+	out := output.WithLayout(port, lay, true)
+	out.Correct = pipe
+	p.out = out
+	p.frames = make(chan []byte, 1)
+	p.errs = make(chan error, 1)
+	p.done = make(chan struct{})
+	go func(out output.Output, frames chan []byte, errs chan error, done chan struct{}) {
+		defer close(done)
+		for frame := range frames {
+			if err := out.Write(frame); err != nil {
+				errs <- err
+				return
+			}
+		}
+	}(out, p.frames, p.errs, p.done)
+	p.status.SetText(fmt.Sprintf("Sending %v LEDs to %v", len(p.pts), p.port.Text()))
+}
+
+// pipeline corrects frames like play does: gamma and brightness, the white
+// point of each fixture and a limit on the current drawn
+func (p *Preview) pipeline() (*correct.Pipeline, error) {
+	pipe := correct.NewPipeline(float64(ParseFloat32(p.gamma.Text(), 2.2)), float64(ParseFloat32(p.bright.Text(), 1)))
+	if p.white.Text() != "" {
+		counts := []int{}
+		for _, f := range p.app.scene.fixtures {
+			counts = append(counts, f.Length())
+		}
+		if err := pipe.SetWhites(p.white.Text(), counts); err != nil {
+			return nil, err
+		}
+	}
+	if amps := ParseFloat32(p.maxAmps.Text(), 0); amps > 0 {
+		pipe.Limiter = &correct.Limiter{Amps: float64(amps), ChannelMilliamps: 20, IdleMilliamps: 1}
+	}
+	return pipe, nil
+}
+
+func (p *Preview) stopOutput() {
+	if p.out == nil {
+		return
+	}
+	close(p.frames)
+	// the port stays open until the last write is done
+	<-p.done
+	p.out.Close()
+	p.out = nil
+}
@@ -1,7 +1,6 @@
 package app
 
 import (
-	"path/filepath"
 	"strconv"
 
 	"github.com/g3n/engine/geometry"
@@ -13,8 +12,12 @@ import (
 	"github.com/g3n/engine/util/logger"
 	color "github.com/gerow/go-color"
 	"github.com/tgreiser/cymapper/cmd/scenebuild/fixture"
+	"github.com/tgreiser/cymapper/cmd/scenebuild/history"
 )
 
+// duplicateOffset is how far a duplicated fixture is moved from the original
+const duplicateOffset = 20
+
 type SceneUI struct {
 	devId       *gui.Edit
 	fs          *FileSelect // File select dialog
@@ -29,32 +32,46 @@ type SceneUI struct {
 	tly         *gui.Edit // Top left y
 	brx         *gui.Edit // Bottom right x
 	bry         *gui.Edit // Bottom right y
+	angle       *gui.Edit // Degrees to rotate the current fixture by
+	name        *gui.Edit // Name of the current fixture
 	log         *logger.Logger
 	app         *App
+	cpanel      *gui.Panel                // Control panel, clicks on it are not canvas clicks
+	list        *gui.DropDown             // Fixtures drop down
+	drag        *drag                     // Fixture being dragged on the canvas, nil when not dragging
+	shift       bool                      // Shift is held, constrains dragging
+	group       map[*fixture.Fixture]bool // Fixtures ctrl-clicked into the selection
+	grid        *gui.Edit                 // Grid spacing to snap to, 0 for none
+	snapEdges   *gui.CheckRadio           // Snap to the edges of fixtures and the scene
+	history     history.History           // Changes that can be undone
 }
 
 func (s *SceneUI) Initialize(app *App) {
 	s.log = app.Log()
 	s.app = app
 
-	s.sceneWidth = 1280
-	s.sceneHeight = 720
-	s.selected = -1
+	// the scene is kept when coming back from another screen
+	if s.sceneWidth == 0 {
+		s.sceneWidth = 1280
+		s.sceneHeight = 720
+		s.selected = -1
+	}
 
 	// Adds control panel after the header
-	cpanel := gui.NewPanel(800, 120)
+	cpanel := gui.NewPanel(800, 150)
 	cpanel.SetBorders(0, 0, 1, 0)
 	cpanel.SetPaddings(4, 4, 4, 4)
 	cpanel.SetColor(math32.NewColorHex(0xffca6e))
 	cpanel.SetLayoutParams(&gui.DockLayoutParams{Edge: gui.DockTop})
+	s.cpanel = cpanel
 
-	l2 := gui.NewLabel("Build a scene by adding, moving and resizing fixture maps.")
+	l2 := gui.NewLabel("Build a scene by adding, moving and resizing fixture maps. Drag fixtures and their handles on the canvas, hold shift to keep proportions and ctrl-click to select several.")
 	l2.SetPosition(0, 0)
 	l2.SetPaddings(2, 2, 2, 2)
 	l2.SetColor(darkTextColor)
 	cpanel.Add(l2)
 
-	fixtures := s.newFixturesDropDown(cpanel)
+	s.newFixturesDropDown(cpanel)
 
 	bAddFixture := gui.NewButton("Add Fixture")
 	bAddFixture.SetPosition(4, 22)
@@ -131,7 +148,11 @@ func (s *SceneUI) Initialize(app *App) {
 	s.height.SetText(FormatFloat32(s.sceneHeight))
 	s.height.SetPosition(650, 52)
 	drawBounds := func(name string, ev interface{}) {
+		before := [2]float32{s.sceneWidth, s.sceneHeight}
 		s.Draw()
+		if after := [2]float32{s.sceneWidth, s.sceneHeight}; after != before {
+			s.record(&resizeCanvas{before: before, after: after})
+		}
 	}
 	s.width.Subscribe(gui.OnChange, drawBounds)
 	s.height.Subscribe(gui.OnChange, drawBounds)
@@ -152,24 +173,88 @@ func (s *SceneUI) Initialize(app *App) {
 	bReset.SetPosition(98, 22)
 	bReset.SetWidth(60)
 	bReset.Subscribe(gui.OnClick, func(name string, ev interface{}) {
-		app.Scene().RemoveAll(true)
-		//app.setupScene()
-		fixtures.SelectPos(-1)
+		if len(s.fixtures) > 0 {
+			s.execute(&clearFixtures{})
+		}
+	})
+	cpanel.Add(bReset)
 
-		// Removes and then creates new fixture panel because it's a pain to modify
-		cpanel.Remove(fixtures)
-		fixtures = s.newFixturesDropDown(cpanel)
+	// Fixture list controls, the list order is the address order of the scene
+	s.name = gui.NewEdit(90, "")
+	s.name.SetPosition(98, 54)
+	cpanel.Add(s.name)
 
-		s.selected = -1
-		s.fixtures = nil
+	bRename := gui.NewButton("Rename")
+	bRename.SetPosition(192, 52)
+	bRename.SetWidth(56)
+	bRename.Subscribe(gui.OnClick, func(name string, ev interface{}) {
+		if s.selected < 0 || s.name.Text() == "" || s.name.Text() == s.CurrentFixture().Name() {
+			return
+		}
+		s.execute(&renameFixture{index: s.selected, before: s.CurrentFixture().Name(), after: s.name.Text()})
+	})
+	cpanel.Add(bRename)
+
+	bUp := gui.NewButton("Up")
+	bUp.SetPosition(252, 52)
+	bUp.SetWidth(40)
+	bUp.Subscribe(gui.OnClick, func(name string, ev interface{}) {
+		if s.selected > 0 {
+			s.execute(&moveFixture{from: s.selected, to: s.selected - 1})
+		}
+	})
+	cpanel.Add(bUp)
+
+	bDown := gui.NewButton("Down")
+	bDown.SetPosition(296, 52)
+	bDown.SetWidth(44)
+	bDown.Subscribe(gui.OnClick, func(name string, ev interface{}) {
+		if s.selected >= 0 && s.selected < len(s.fixtures)-1 {
+			s.execute(&moveFixture{from: s.selected, to: s.selected + 1})
+		}
+	})
+	cpanel.Add(bDown)
 
-		s.tlx.SetText("")
-		s.tly.SetText("")
-		s.brx.SetText("")
-		s.bry.SetText("")
-		s.Draw()
+	bDuplicate := gui.NewButton("Duplicate")
+	bDuplicate.SetPosition(98, 82)
+	bDuplicate.SetWidth(70)
+	bDuplicate.Subscribe(gui.OnClick, func(name string, ev interface{}) {
+		if s.selected < 0 {
+			return
+		}
+		// offset the copy so it is not hidden under the original
+		dup := s.CurrentFixture().Clone()
+		dup.SetName(dup.Name() + " copy")
+		dup.ApplyTransformation(fixture.Translation(duplicateOffset, -duplicateOffset))
+		s.execute(&addFixture{fixture: dup, index: s.selected + 1})
 	})
-	cpanel.Add(bReset)
+	cpanel.Add(bDuplicate)
+
+	bDelete := gui.NewButton("Delete")
+	bDelete.SetPosition(172, 82)
+	bDelete.SetWidth(56)
+	bDelete.Subscribe(gui.OnClick, func(name string, ev interface{}) {
+		if s.selected >= 0 {
+			s.execute(&removeFixture{addFixture{index: s.selected}})
+		}
+	})
+	cpanel.Add(bDelete)
+
+	bUndo := gui.NewButton("Undo")
+	bUndo.SetPosition(4, 82)
+	bUndo.SetWidth(44)
+	bUndo.Subscribe(gui.OnClick, func(name string, ev interface{}) {
+		s.undo()
+	})
+	cpanel.Add(bUndo)
+
+	bRedo := gui.NewButton("Redo")
+	bRedo.SetPosition(50, 82)
+	bRedo.SetWidth(44)
+	bRedo.Subscribe(gui.OnClick, func(name string, ev interface{}) {
+		s.redo()
+	})
+	cpanel.Add(bRedo)
 
 	xform := func(name string, ev interface{}) {
 		// use app.selected to calculate transformations
@@ -177,6 +262,9 @@ func (s *SceneUI) Initialize(app *App) {
 		// orig BR - app.fixtures[app.selected].br
 		// new TL - tlx.Text(), tly.Text()
 		// new BR - brx.Text(), bry.Text()
+		if s.selected < 0 {
+			return
+		}
 		ntlx, err := strconv.ParseFloat(s.tlx.Text(), 32)
 		if err != nil {
 			app.Log().Error("Invalid top left coordinates %v\n", s.tlx.Text())
@@ -194,7 +282,9 @@ func (s *SceneUI) Initialize(app *App) {
 			app.Log().Error("Invalid bottom right coordinates %v\n", s.bry.Text())
 		}
 
+		before := s.CurrentFixture().State()
 		s.transformFixtureTo(s.CurrentFixture(), ntlx, ntly, nbrx, nbry)
+		s.recordFixture(s.selected, before, true)
 	}
 	s.tlx.Subscribe(gui.OnChange, xform)
 	s.tly.Subscribe(gui.OnChange, xform)
@@ -217,6 +307,18 @@ func (s *SceneUI) Initialize(app *App) {
 	})
 	cpanel.Add(bFlipY)
 
+	s.angle = gui.NewEdit(40, "90")
+	s.angle.SetPosition(260, 82)
+	cpanel.Add(s.angle)
+
+	bRotate := gui.NewButton("Rotate")
+	bRotate.SetPosition(304, 80)
+	bRotate.SetWidth(60)
+	bRotate.Subscribe(gui.OnClick, func(name string, ev interface{}) {
+		s.rotate(ParseFloat32(s.angle.Text(), 0))
+	})
+	cpanel.Add(bRotate)
+
 	// Save Scene - File Select
 	ss, err := NewFileSelect(400, 300, "../../fixtures")
 	if err != nil {
@@ -262,23 +364,20 @@ func (s *SceneUI) Initialize(app *App) {
 		}
 		app.log.Info("Selected file: %v", fpath)
 		// parse relative vectors for fixture
-		s.newFixture(fpath)
-		s.DrawFixtures()
 		s.fs.Show(false)
-		newFixture := gui.NewImageLabel(filepath.Base(fpath))
-		fixtures.Add(newFixture)
-		fixtures.SelectPos(fixtures.Len() - 1)
-		s.selected = fixtures.Len() - 1
-
-		s.SetCorners()
-		s.Draw()
+		s.execute(&addFixture{fixture: fixture.NewFixture(fpath), index: len(s.fixtures)})
 	})
 	s.fs.Subscribe("OnCancel", func(evname string, ev interface{}) {
 		s.fs.Show(false)
 	})
 	cpanel.Add(s.fs)
 
+	s.addAlignControls(cpanel)
+
 	app.GuiPanel().Add(cpanel)
+	s.subscribeCanvas()
+	s.subscribeHistory()
+	s.refresh()
 }
 
 func (s *SceneUI) Render(a *App) {
@@ -291,6 +390,7 @@ func (s *SceneUI) newFixturesDropDown(cpanel *gui.Panel) *gui.DropDown {
 	fixtures.SelectPos(-1)
 
 	cpanel.Add(fixtures)
+	s.list = fixtures
 	fixtures.Subscribe(gui.OnChange, func(name string, ev interface{}) {
 		s.selected = fixtures.SelectedPos()
 		//app.Log().Debug("Change fixture %v %v", fixtures.SelectedPos(), fixtures.Selected().Text())
@@ -303,15 +403,29 @@ func (s *SceneUI) newFixturesDropDown(cpanel *gui.Panel) *gui.DropDown {
 func (s *SceneUI) transformFixtureTo(fixt *fixture.Fixture, ntlx, ntly, nbrx, nbry float64) {
 	newTL := math32.NewVector3(float32(ntlx), float32(ntly), 0)
 	newBR := math32.NewVector3(float32(nbrx), float32(nbry), 0)
-	sc, tr := fixture.NewTransformation(fixt.TopLeft(),
-		fixt.BottomRight(), newTL, newBR)
-	fixt.Transform(sc, tr)
+	// fit the current bounding box to the new corners, keeping any rotation
+	sc, tr := fixture.NewTransformation(fixt.TransformedTopLeft(),
+		fixt.TransformedBottomRight(), newTL, newBR)
+	fixt.ApplyTransformation(fixture.NewAffine(sc, tr))
 	s.Log().Debug("selected %v x %v\n", fixt.TopLeft(), fixt.BottomRight())
 	s.Log().Debug("SC %v x %v TR %v x %v\n", sc.X, sc.Y, tr.X, tr.Y)
 
 	s.Draw()
 }
 
+// rotate turns the current fixture counter clockwise about its center
+func (s *SceneUI) rotate(degrees float32) {
+	if s.selected < 0 {
+		return
+	}
+	fixt := s.CurrentFixture()
+	before := fixt.State()
+	fixt.ApplyTransformation(fixture.Rotation(math32.DegToRad(degrees), fixt.TransformedCenter()))
+	s.recordFixture(s.selected, before, false)
+	s.SetCorners()
+	s.Draw()
+}
+
 func (s *SceneUI) flip(direction string) {
 	topLeftX, err := strconv.ParseFloat(s.tlx.Text(), 32)
 	if err != nil {
@@ -334,6 +448,7 @@ func (s *SceneUI) flip(direction string) {
 		return
 	}
 	currentFixture := s.CurrentFixture()
+	before := currentFixture.State()
 	if direction == "X" {
 		// Swap Y values of current fixture.
 		s.transformFixtureTo(currentFixture, topLeftX, bottomRightY, bottomRightX, topLeftY)
@@ -344,7 +459,10 @@ func (s *SceneUI) flip(direction string) {
 		return
 	}
 
+	// baking the flip into the points keeps later transformations simple,
+	// the previous points are kept in the history
 	currentFixture.UpdatePoints()
+	s.recordFixture(s.selected, before, false)
 	// currentFixture.tl, currentFixture.br = currentFixture.FindCorners(currentFixture.pts)
 
 	// app.tly.SetText(bottomRightY)
@@ -354,11 +472,6 @@ func (s *SceneUI) flip(direction string) {
 	s.Draw()
 }
 
-func (s *SceneUI) newFixture(filePath string) {
-	newFixture := fixture.NewFixture(filePath)
-	s.fixtures = append(s.fixtures, newFixture)
-}
-
 func (s *SceneUI) SetCorners() {
 	if s.selected >= 0 {
 		fixture := s.fixtures[s.selected]
@@ -366,6 +479,7 @@ func (s *SceneUI) SetCorners() {
 		s.tly.SetText(FormatFloat32(fixture.TransformedTopLeft().Y))
 		s.brx.SetText(FormatFloat32(fixture.TransformedBottomRight().X))
 		s.bry.SetText(FormatFloat32(fixture.TransformedBottomRight().Y))
+		s.name.SetText(fixture.Name())
 	}
 }
 
@@ -452,18 +566,13 @@ func (s *SceneUI) NewRainbowMaterial(hue float64) *material.Standard {
 }
 
 func (s *SceneUI) DrawFixtures() {
-	rmat := material.NewStandard(math32.NewColor("red"))
-	rmat.SetSide(material.SideFront)
-	rmat.SetWireframe(true)
-	rmat.SetLineWidth(1)
-
 	for iX, fixture := range s.fixtures {
 		// add fixture vectors to scene
 		fixture.Reset()
 		s.app.Log().Debug("fixture %v", iX)
 
 		for j := 0; fixture.Available(); j++ {
-			geom := geometry.NewCircle(3, 16)
+			geom := geometry.NewCircle(ledRadius, 16)
 			mat := s.NewRainbowMaterial(float64(j) / float64(fixture.Length()) * 0.67)
 			circle := graphic.NewMesh(geom, mat)
 			circle.SetPositionVec(fixture.Next())
@@ -471,12 +580,9 @@ func (s *SceneUI) DrawFixtures() {
 			//s.app.Log().Debug("%v", circle.Position())
 		}
 		if iX == s.selected {
-			circle := graphic.NewMesh(geometry.NewCircle(6, 16), rmat)
-			circle.SetPositionVec(fixture.TransformedTopLeft())
-			s.app.Scene().Add(circle)
-			circle = graphic.NewMesh(geometry.NewCircle(6, 16), rmat)
-			circle.SetPositionVec(fixture.TransformedBottomRight())
-			s.app.Scene().Add(circle)
+			s.DrawHandles(fixture)
+		} else if s.group[fixture] {
+			s.DrawOutline(fixture)
 		}
 	}
 
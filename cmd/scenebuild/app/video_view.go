@@ -0,0 +1,39 @@
+package app
+
+import (
+	"image"
+
+	"github.com/g3n/engine/gui"
+	"github.com/g3n/engine/texture"
+)
+
+// videoView shows camera frames in the gui, reusing one texture until the
+// frame size changes
+type videoView struct {
+	tex  *texture.Texture2D
+	size image.Point
+	img  *gui.Image
+}
+
+// show draws a frame at y on the panel
+func (v *videoView) show(panel *gui.Panel, rgba *image.RGBA, y float32) {
+	if v.tex != nil && v.size == rgba.Rect.Size() {
+		v.tex.SetFromRGBA(rgba)
+		return
+	}
+	if v.img != nil {
+		panel.Remove(v.img)
+		v.tex.Dispose()
+	}
+	v.tex = texture.NewTexture2DFromRGBA(rgba)
+	v.size = rgba.Rect.Size()
+	v.img = gui.NewImageFromTex(v.tex)
+	v.img.SetPosition(0, y)
+	panel.Add(v.img)
+}
+
+func (v *videoView) dispose() {
+	if v.tex != nil {
+		v.tex.Dispose()
+	}
+}
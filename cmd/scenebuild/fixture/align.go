@@ -0,0 +1,120 @@
+package fixture
+
+import (
+	"sort"
+
+	"github.com/g3n/engine/math32"
+)
+
+// Snapper moves dragged fixtures onto nearby edges or a grid
+type Snapper struct {
+	Grid  float32   // Grid spacing, 0 turns the grid off
+	Reach float32   // Edges closer than this are snapped to
+	X     []float32 // Vertical edges to snap to
+	Y     []float32 // Horizontal edges to snap to
+}
+
+// Offset returns how far to move a box with vertical edges xs and horizontal
+// edges ys so it snaps. The closest edge within reach wins, otherwise the
+// first edge of each axis snaps to the grid.
+func (s Snapper) Offset(xs, ys []float32) (dx, dy float32) {
+	return s.offset(xs, s.X), s.offset(ys, s.Y)
+}
+
+func (s Snapper) offset(edges, targets []float32) float32 {
+	best, delta, found := s.Reach, float32(0), false
+	for _, e := range edges {
+		for _, t := range targets {
+			if d := t - e; math32.Abs(d) <= best {
+				best, delta, found = math32.Abs(d), d, true
+			}
+		}
+	}
+	if found || s.Grid <= 0 || len(edges) == 0 {
+		return delta
+	}
+	return math32.Round(edges[0]/s.Grid)*s.Grid - edges[0]
+}
+
+// Edges returns the left, center and right X and the bottom, middle and top
+// Y of the transformed fixture
+func Edges(f *Fixture) (xs, ys []float32) {
+	tl, br := f.TransformedTopLeft(), f.TransformedBottomRight()
+	return []float32{tl.X, (tl.X + br.X) / 2, br.X}, []float32{br.Y, (tl.Y + br.Y) / 2, tl.Y}
+}
+
+// Align moves the fixtures so they line up on one edge of their combined
+// bounding box: left, center, right, top, middle or bottom
+func Align(fixtures []*Fixture, edge string) {
+	if len(fixtures) == 0 {
+		return
+	}
+	minX, maxX := fixtures[0].TransformedTopLeft().X, fixtures[0].TransformedBottomRight().X
+	minY, maxY := fixtures[0].TransformedBottomRight().Y, fixtures[0].TransformedTopLeft().Y
+	for _, f := range fixtures {
+		minX = math32.Min(minX, f.TransformedTopLeft().X)
+		maxX = math32.Max(maxX, f.TransformedBottomRight().X)
+		minY = math32.Min(minY, f.TransformedBottomRight().Y)
+		maxY = math32.Max(maxY, f.TransformedTopLeft().Y)
+	}
+	for _, f := range fixtures {
+		tl, br, c := f.TransformedTopLeft(), f.TransformedBottomRight(), f.TransformedCenter()
+		var dx, dy float32
+		switch edge {
+		case "left":
+			dx = minX - tl.X
+		case "center":
+			dx = (minX+maxX)/2 - c.X
+		case "right":
+			dx = maxX - br.X
+		case "top":
+			dy = maxY - tl.Y
+		case "middle":
+			dy = (minY+maxY)/2 - c.Y
+		case "bottom":
+			dy = minY - br.Y
+		}
+		f.ApplyTransformation(Translation(dx, dy))
+	}
+}
+
+// Distribute spaces the fixtures evenly along the X or Y axis, leaving the
+// first and last in place and making the gaps between them equal
+func Distribute(fixtures []*Fixture, axis string) {
+	if len(fixtures) < 3 {
+		return
+	}
+	// low and high edges of a fixture along the axis
+	span := func(f *Fixture) (lo, hi float32) {
+		if axis == "Y" {
+			return f.TransformedBottomRight().Y, f.TransformedTopLeft().Y
+		}
+		return f.TransformedTopLeft().X, f.TransformedBottomRight().X
+	}
+	sorted := append([]*Fixture{}, fixtures...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		li, hi := span(sorted[i])
+		lj, hj := span(sorted[j])
+		return li+hi < lj+hj
+	})
+
+	start, _ := span(sorted[0])
+	_, end := span(sorted[len(sorted)-1])
+	var size float32
+	for _, f := range sorted {
+		lo, hi := span(f)
+		size += hi - lo
+	}
+	gap := (end - start - size) / float32(len(sorted)-1)
+
+	pos := start
+	for _, f := range sorted {
+		lo, hi := span(f)
+		if axis == "Y" {
+			f.ApplyTransformation(Translation(0, pos-lo))
+		} else {
+			f.ApplyTransformation(Translation(pos-lo, 0))
+		}
+		pos += hi - lo + gap
+	}
+}
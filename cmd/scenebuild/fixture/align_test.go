@@ -0,0 +1,44 @@
+package fixture
+
+import (
+	"testing"
+
+	"github.com/g3n/engine/math32"
+)
+
+// box returns a fixture covering x, y to x+w, y+h
+func box(x, y, w, h float32) *Fixture {
+	f := &Fixture{pts: []*math32.Vector3{math32.NewVector3(0, 0, 0), math32.NewVector3(w, h, 0)}}
+	f.tl, f.br = f.FindCorners(f.pts)
+	f.SetTransformation(Translation(x, y))
+	return f
+}
+
+func TestAlign(t *testing.T) {
+	a, b := box(0, 0, 10, 10), box(30, 20, 20, 5)
+	Align([]*Fixture{a, b}, "right")
+	if a.TransformedBottomRight().X != 50 || b.TransformedBottomRight().X != 50 {
+		t.Errorf("Right edges %v and %v, expected 50", a.TransformedBottomRight().X, b.TransformedBottomRight().X)
+	}
+	Align([]*Fixture{a, b}, "middle")
+	if a.TransformedCenter().Y != b.TransformedCenter().Y {
+		t.Errorf("Middles %v and %v did not match", a.TransformedCenter().Y, b.TransformedCenter().Y)
+	}
+}
+
+func TestDistribute(t *testing.T) {
+	a, b, c := box(0, 0, 10, 10), box(60, 0, 20, 10), box(12, 0, 10, 10)
+	Distribute([]*Fixture{a, b, c}, "X")
+	// 80 wide with 40 of fixtures leaves two gaps of 20
+	if a.TransformedTopLeft().X != 0 || c.TransformedTopLeft().X != 30 || b.TransformedTopLeft().X != 60 {
+		t.Errorf("Fixtures at %v, %v, %v, expected 0, 30, 60",
+			a.TransformedTopLeft().X, c.TransformedTopLeft().X, b.TransformedTopLeft().X)
+	}
+}
+
+func TestSnapper(t *testing.T) {
+	s := Snapper{Grid: 25, Reach: 4, X: []float32{100}, Y: []float32{0}}
+	if dx, dy := s.Offset([]float32{40, 70, 97}, []float32{33, 36, 39}); dx != 3 || dy != -8 {
+		t.Errorf("Offset %v x %v, expected an edge snap of 3 and a grid snap of -8", dx, dy)
+	}
+}
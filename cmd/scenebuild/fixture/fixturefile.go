@@ -6,33 +6,48 @@ import (
 	"io"
 	"log"
 	"os"
+	"path/filepath"
 	"strconv"
 
 	"github.com/g3n/engine/math32"
 )
 
 type Fixture struct {
-	filepath  string            // File path
-	pts       []*math32.Vector3 // List of relative LED coordinates
-	tpts      []*math32.Vector3 // List of transformed coordinates
-	tl        *math32.Vector3   // Top left corner
-	br        *math32.Vector3   // Bottom right corner
-	ttl       *math32.Vector3   // Transformed Top left corner
-	tbr       *math32.Vector3   // Transformed Bottom right corner
-	idx       int               // internal pointer
-	translate *math32.Vector3   // translate
-	scale     *math32.Vector3   // matrix multiply to scale points
+	filepath string            // File path
+	name     string            // Display name, defaults to the file name
+	pts      []*math32.Vector3 // List of relative LED coordinates
+	tpts     []*math32.Vector3 // List of transformed coordinates
+	tl       *math32.Vector3   // Top left corner
+	br       *math32.Vector3   // Bottom right corner
+	ttl      *math32.Vector3   // Transformed Top left corner
+	tbr      *math32.Vector3   // Transformed Bottom right corner
+	idx      int               // internal pointer
+	xform    Affine            // transformation from pts to tpts
 }
 
+// State is a snapshot of the points and transformation of a fixture, used
+// to undo changes
+type State struct {
+	pts   []*math32.Vector3
+	tl    *math32.Vector3
+	br    *math32.Vector3
+	xform Affine
+}
+
+// NewFixture loads a map or scene TSV. Points are used as they are, with y
+// pointing up like the scene, so a camera map, which counts y down, shows
+// upside down until it is flipped.
 func NewFixture(path string) *Fixture {
 	f := new(Fixture)
 	f.filepath = path
+	f.name = filepath.Base(path)
 	tsv, err := os.Open(path)
 	if err != nil {
 		log.Printf("Invalid TSV file path: %v\n", path)
 	}
 	reader := csv.NewReader(bufio.NewReader(tsv))
 	reader.Comma = '\t'
+	reader.FieldsPerRecord = -1
 	for {
 		line, error := reader.Read()
 		if error == io.EOF {
@@ -50,16 +65,30 @@ func NewFixture(path string) *Fixture {
 			log.Printf("ERROR: invalid data in %v: %v\n", path, line[1])
 			continue
 		}
-		f.pts = append(f.pts, math32.NewVector3(float32(x), float32(y), 0))
+		// triangulated maps have a third column with depth
+		z := 0.0
+		if len(line) > 2 {
+			z, err = strconv.ParseFloat(line[2], 32)
+			if err != nil {
+				log.Printf("ERROR: invalid data in %v: %v\n", path, line[2])
+				continue
+			}
+		}
+		f.pts = append(f.pts, math32.NewVector3(float32(x), float32(y), float32(z)))
 	}
 	f.tl, f.br = f.FindCorners(f.pts)
 	f.ResetTransformation()
 	return f
 }
 
+// FindCorners returns the corners of the bounding box around pts. Y points up
+// in the scene, so the top left corner has the largest Y.
 func (f *Fixture) FindCorners(pts []*math32.Vector3) (topLeft, bottomRight *math32.Vector3) {
-	var ftlx, ftly float32 = 10000.0, 0.01
-	var fbrx, fbry float32 = 0.0, 10000.0
+	if len(pts) == 0 {
+		return math32.NewVector3(0, 0, 0), math32.NewVector3(0, 0, 0)
+	}
+	ftlx, ftly := pts[0].X, pts[0].Y
+	fbrx, fbry := pts[0].X, pts[0].Y
 	for _, p := range pts {
 		if float32(p.X) < ftlx {
 			ftlx = float32(p.X)
@@ -78,8 +107,7 @@ func (f *Fixture) FindCorners(pts []*math32.Vector3) (topLeft, bottomRight *math
 }
 
 func (f *Fixture) ResetTransformation() {
-	f.Transform(math32.NewVector3(1.0, 1.0, 1.0),
-		math32.NewVector3(0.0, 0.0, 0.0))
+	f.SetTransformation(Identity())
 }
 
 func (f *Fixture) Available() bool {
@@ -99,6 +127,54 @@ func (f *Fixture) Reset() {
 	f.idx = 0
 }
 
+// State returns a snapshot of the fixture. Points are never modified in
+// place, so the snapshot shares them with the fixture.
+func (f *Fixture) State() State {
+	return State{pts: f.pts, tl: f.tl, br: f.br, xform: f.xform}
+}
+
+// Restore returns the fixture to a snapshot taken by State
+func (f *Fixture) Restore(st State) {
+	f.pts = st.pts
+	f.tl = st.tl
+	f.br = st.br
+	f.SetTransformation(st.xform)
+}
+
+// Equal reports whether two snapshots hold the same points and transformation
+func (st State) Equal(o State) bool {
+	if st.xform != o.xform || len(st.pts) != len(o.pts) {
+		return false
+	}
+	for iX := range st.pts {
+		if st.pts[iX] != o.pts[iX] {
+			return false
+		}
+	}
+	return true
+}
+
+// Path returns the TSV file the fixture was loaded from
+func (f Fixture) Path() string {
+	return f.filepath
+}
+
+// Name returns the display name of the fixture
+func (f Fixture) Name() string {
+	return f.name
+}
+
+func (f *Fixture) SetName(name string) {
+	f.name = name
+}
+
+// Clone returns a copy of the fixture that can be transformed separately
+func (f *Fixture) Clone() *Fixture {
+	c := *f
+	c.SetTransformation(f.xform)
+	return &c
+}
+
 func (f Fixture) TopLeft() *math32.Vector3 {
 	return f.tl
 }
@@ -121,20 +197,55 @@ func (f *Fixture) UpdatePoints() {
 	f.pts = f.tpts
 	f.tl = f.ttl
 	f.br = f.tbr
+	f.xform = Identity()
 }
 
 func (f *Fixture) Transformed() []*math32.Vector3 {
 	f.tpts = make([]*math32.Vector3, len(f.pts), len(f.pts))
 	for iP, p := range f.pts {
-		f.tpts[iP] = math32.NewVector3(
-			(p.X*f.scale.X)+f.translate.X,
-			(p.Y*f.scale.Y)+f.translate.Y, 0)
+		f.tpts[iP] = f.xform.Apply(p)
 	}
 	return f.tpts
 }
 
+// Transform replaces the transformation with a per axis scale and translate
 func (f *Fixture) Transform(scale, translate *math32.Vector3) {
-	f.scale = scale
-	f.translate = translate
+	f.SetTransformation(NewAffine(scale, translate))
+}
+
+// SetTransformation replaces the transformation and recalculates the
+// transformed points and the corners of their bounding box
+func (f *Fixture) SetTransformation(xform Affine) {
+	f.xform = xform
 	f.ttl, f.tbr = f.FindCorners(f.Transformed())
 }
+
+// Transformation returns the current transformation
+func (f *Fixture) Transformation() Affine {
+	return f.xform
+}
+
+// ApplyTransformation adds xform after the current transformation, so a
+// rotation keeps any scale or translate already applied
+func (f *Fixture) ApplyTransformation(xform Affine) {
+	f.SetTransformation(xform.Multiply(f.xform))
+}
+
+// Near reports whether p is within reach of a transformed LED, ignoring depth
+func (f *Fixture) Near(p *math32.Vector3, reach float32) bool {
+	if p.X < f.ttl.X-reach || p.X > f.tbr.X+reach || p.Y < f.tbr.Y-reach || p.Y > f.ttl.Y+reach {
+		return false
+	}
+	for _, t := range f.tpts {
+		dx, dy := t.X-p.X, t.Y-p.Y
+		if dx*dx+dy*dy <= reach*reach {
+			return true
+		}
+	}
+	return false
+}
+
+// TransformedCenter returns the center of the transformed bounding box
+func (f *Fixture) TransformedCenter() *math32.Vector3 {
+	return math32.NewVector3((f.ttl.X+f.tbr.X)/2, (f.ttl.Y+f.tbr.Y)/2, 0)
+}
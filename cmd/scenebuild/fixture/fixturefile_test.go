@@ -0,0 +1,80 @@
+package fixture
+
+import (
+	"testing"
+
+	"github.com/g3n/engine/math32"
+)
+
+// square returns a fixture with LEDs at 0, 0 and size, size
+func square(size float32) *Fixture {
+	f := &Fixture{pts: []*math32.Vector3{math32.NewVector3(0, 0, 0), math32.NewVector3(size, size, 0)}}
+	f.tl, f.br = f.FindCorners(f.pts)
+	f.ResetTransformation()
+	return f
+}
+
+func TestStateRestore(t *testing.T) {
+	f := square(10)
+	before := f.State()
+	if !before.Equal(f.State()) {
+		t.Error("State did not equal itself")
+	}
+
+	// flattening replaces the points, which the snapshot keeps
+	f.ApplyTransformation(Translation(5, 5))
+	f.UpdatePoints()
+	f.ApplyTransformation(Rotation(math32.Pi/2, f.TransformedCenter()))
+	if before.Equal(f.State()) {
+		t.Error("State equal after moving and rotating")
+	}
+
+	f.Restore(before)
+	if !before.Equal(f.State()) {
+		t.Error("State not equal after restoring")
+	}
+	if tl, br := f.TransformedTopLeft(), f.TransformedBottomRight(); tl.X != 0 || tl.Y != 10 || br.X != 10 || br.Y != 0 {
+		t.Errorf("Restored to %v - %v, expected 0,10 - 10,0", tl, br)
+	}
+}
+
+func TestClone(t *testing.T) {
+	f := square(10)
+	c := f.Clone()
+	c.ApplyTransformation(Translation(20, 0))
+	if f.TransformedTopLeft().X != 0 || f.Transformed()[1].X != 10 {
+		t.Errorf("Moving the clone moved the original to %v", f.TransformedTopLeft())
+	}
+	if c.TransformedTopLeft().X != 20 || c.Transformed()[1].X != 30 {
+		t.Errorf("Clone at %v, expected 20", c.TransformedTopLeft())
+	}
+	if f.State().Equal(c.State()) {
+		t.Error("Clone state equal to the original after moving")
+	}
+}
+
+func TestNear(t *testing.T) {
+	// LEDs along the left and bottom edges of a 100 x 100 square
+	f := &Fixture{}
+	for i := float32(0); i <= 100; i += 10 {
+		f.pts = append(f.pts, math32.NewVector3(0, i, 0), math32.NewVector3(i, 0, 0))
+	}
+	f.tl, f.br = f.FindCorners(f.pts)
+	f.SetTransformation(Translation(50, 0))
+	for _, c := range []struct {
+		x, y     float32
+		expected bool
+	}{
+		{50, 40, true},
+		{52, 43, true},
+		{101, 2, true},
+		// inside the bounding box but away from the LEDs
+		{100, 50, false},
+		{45, 40, false},
+		{50, 115, false},
+	} {
+		if near := f.Near(math32.NewVector3(c.x, c.y, 0), 4); near != c.expected {
+			t.Errorf("Near %v, %v was %v, expected %v", c.x, c.y, near, c.expected)
+		}
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"log"
 	"os"
 	"strconv"
+
+	"github.com/g3n/engine/math32"
 )
 
 type Scene struct {
@@ -27,19 +29,50 @@ func (s *Scene) SaveAs(filename string) error {
 	defer w.Flush()
 	w.Comma = '\t'
 
+	pts := s.Points()
+	depth := HasDepth(pts)
+	for _, pt := range pts {
+		line := []string{strconv.FormatFloat(float64(pt.X), 'f', -1, 64),
+			strconv.FormatFloat(float64(pt.Y), 'f', -1, 64)}
+		if depth {
+			line = append(line, strconv.FormatFloat(float64(pt.Z), 'f', -1, 64))
+		}
+		err := w.Write(line)
+		if err != nil {
+			log.Printf("%v\n", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// Fixtures returns the fixtures of the scene in address order.
+func (s *Scene) Fixtures() []*Fixture {
+	return s.fixtures
+}
+
+// Points returns the transformed points of every fixture, in the same
+// address order used by SaveAs.
+func (s *Scene) Points() []*math32.Vector3 {
+	pts := []*math32.Vector3{}
 	l := len(s.fixtures)
 	for iX := 0; iX < l; iX++ {
 		// add fixture vectors to scene
 		s.fixtures[iX].Reset()
 		for s.fixtures[iX].Available() {
-			pt := s.fixtures[iX].Next()
-			err := w.Write([]string{strconv.FormatFloat(float64(pt.X), 'f', -1, 64),
-				strconv.FormatFloat(float64(pt.Y), 'f', -1, 64)})
-			if err != nil {
-				log.Printf("%v\n", err)
-				return err
-			}
+			pts = append(pts, s.fixtures[iX].Next())
 		}
 	}
-	return nil
+	return pts
+}
+
+// HasDepth reports whether any point has a Z coordinate, as in maps made
+// with cmd/triangulate.
+func HasDepth(pts []*math32.Vector3) bool {
+	for _, p := range pts {
+		if p.Z != 0 {
+			return true
+		}
+	}
+	return false
 }
@@ -1,12 +1,92 @@
 package fixture
 
-import "github.com/g3n/engine/math32"
+import (
+	"errors"
+
+	"github.com/g3n/engine/math32"
+)
 
 func NewTransformation(topLeft, bottomRight, newTopLeft, newBottomRight *math32.Vector3) (scale, translate *math32.Vector3) {
 	scale = math32.NewVector3((newBottomRight.X-newTopLeft.X)/(bottomRight.X-topLeft.X),
 		(newBottomRight.Y-newTopLeft.Y)/(bottomRight.Y-topLeft.Y), 1)
+	// a fixture with no width or height (a straight strip) can only be moved on that axis
+	if bottomRight.X == topLeft.X {
+		scale.X = 1
+	}
+	if bottomRight.Y == topLeft.Y {
+		scale.Y = 1
+	}
 	translate = math32.NewVector3(newTopLeft.X-(scale.X*topLeft.X),
 		newTopLeft.Y-(scale.Y*topLeft.Y),
 		0)
 	return scale, translate
 }
+
+// Affine is a 2D affine transformation matrix, applied to a point as
+//
+//	x' = A[0]*x + A[1]*y + A[2]
+//	y' = A[3]*x + A[4]*y + A[5]
+//
+// Z is passed through unchanged.
+type Affine [6]float32
+
+// Identity returns the transformation that leaves points where they are.
+func Identity() Affine {
+	return Affine{1, 0, 0, 0, 1, 0}
+}
+
+// NewAffine returns the per axis scale and translate used by Fixture.Transform.
+func NewAffine(scale, translate *math32.Vector3) Affine {
+	return Affine{scale.X, 0, translate.X, 0, scale.Y, translate.Y}
+}
+
+// Translation moves points by tx, ty.
+func Translation(tx, ty float32) Affine {
+	return Affine{1, 0, tx, 0, 1, ty}
+}
+
+// Scaling scales points by sx, sy about the pivot.
+func Scaling(sx, sy float32, pivot *math32.Vector3) Affine {
+	return about(Affine{sx, 0, 0, 0, sy, 0}, pivot)
+}
+
+// Rotation rotates points counter clockwise by radians about the pivot.
+func Rotation(radians float32, pivot *math32.Vector3) Affine {
+	c, s := math32.Cos(radians), math32.Sin(radians)
+	return about(Affine{c, -s, 0, s, c, 0}, pivot)
+}
+
+// Shearing shears x by shx * y and y by shy * x, about the pivot.
+func Shearing(shx, shy float32, pivot *math32.Vector3) Affine {
+	return about(Affine{1, shx, 0, shy, 1, 0}, pivot)
+}
+
+// about applies a about pivot instead of the origin
+func about(a Affine, pivot *math32.Vector3) Affine {
+	return Translation(pivot.X, pivot.Y).Multiply(a).Multiply(Translation(-pivot.X, -pivot.Y))
+}
+
+// Multiply returns a * b, the transformation that applies b first and then a.
+func (a Affine) Multiply(b Affine) Affine {
+	return Affine{
+		a[0]*b[0] + a[1]*b[3], a[0]*b[1] + a[1]*b[4], a[0]*b[2] + a[1]*b[5] + a[2],
+		a[3]*b[0] + a[4]*b[3], a[3]*b[1] + a[4]*b[4], a[3]*b[2] + a[4]*b[5] + a[5],
+	}
+}
+
+// Invert returns the transformation that undoes a.
+func (a Affine) Invert() (Affine, error) {
+	det := a[0]*a[4] - a[1]*a[3]
+	if det == 0 {
+		return Affine{}, errors.New("transformation can not be inverted")
+	}
+	return Affine{
+		a[4] / det, -a[1] / det, (a[1]*a[5] - a[4]*a[2]) / det,
+		-a[3] / det, a[0] / det, (a[3]*a[2] - a[0]*a[5]) / det,
+	}, nil
+}
+
+// Apply returns a new vector with the transformation applied to v.
+func (a Affine) Apply(v *math32.Vector3) *math32.Vector3 {
+	return math32.NewVector3(a[0]*v.X+a[1]*v.Y+a[2], a[3]*v.X+a[4]*v.Y+a[5], v.Z)
+}
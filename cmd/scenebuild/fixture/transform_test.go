@@ -33,3 +33,60 @@ func assertTransform(t *testing.T, tlx, tly, brx, bry, ntlx, ntly, nbrx, nbry, s
 		t.Errorf("Translate was incorrect %v x %v did not match %v\n", trx, try, tr)
 	}
 }
+
+func TestAffine(t *testing.T) {
+	pivot := math32.NewVector3(10, 10, 0)
+	// 90 degrees counter clockwise about 10 x 10
+	assertApply(t, Rotation(math32.Pi/2, pivot), 20, 10, 10, 20)
+	assertApply(t, Scaling(2, 3, pivot), 20, 20, 30, 40)
+	assertApply(t, Shearing(1, 0, pivot), 10, 20, 20, 20)
+	assertApply(t, Translation(5, -5), 1, 1, 6, -4)
+
+	// b is applied before a
+	a := Translation(100, 0)
+	b := Rotation(math32.Pi/2, math32.NewVector3(0, 0, 0))
+	assertApply(t, a.Multiply(b), 1, 0, 100, 1)
+
+	xform := Rotation(0.5, pivot).Multiply(Scaling(2, 0.5, pivot)).Multiply(Translation(3, 4))
+	inv, err := xform.Invert()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertApply(t, inv.Multiply(xform), 7, -3, 7, -3)
+
+	if _, err := Scaling(0, 1, pivot).Invert(); err == nil {
+		t.Errorf("Invert of a flattening transformation did not fail")
+	}
+}
+
+func TestFixtureRotation(t *testing.T) {
+	// a horizontal strip mounted 30 degrees off
+	f := &Fixture{}
+	for iX := 0; iX <= 10; iX++ {
+		p := math32.NewVector3(float32(iX)*10, 0, 0)
+		f.pts = append(f.pts, Rotation(math32.DegToRad(30), math32.NewVector3(0, 0, 0)).Apply(p))
+	}
+	f.tl, f.br = f.FindCorners(f.pts)
+	f.ResetTransformation()
+
+	f.ApplyTransformation(Rotation(math32.DegToRad(-30), f.TransformedCenter()))
+	tl, br := f.TransformedTopLeft(), f.TransformedBottomRight()
+	if math32.Abs(tl.Y-br.Y) > 1e-3 {
+		t.Errorf("Strip was not straightened, corners %v x %v", tl, br)
+	}
+	if math32.Abs(br.X-tl.X-100) > 1e-3 {
+		t.Errorf("Strip length changed, corners %v x %v", tl, br)
+	}
+
+	f.UpdatePoints()
+	if f.Transformation() != Identity() {
+		t.Errorf("UpdatePoints did not reset the transformation: %v", f.Transformation())
+	}
+}
+
+func assertApply(t *testing.T, a Affine, x, y, ex, ey float32) {
+	v := a.Apply(math32.NewVector3(x, y, 7))
+	if math32.Abs(v.X-ex) > 1e-4 || math32.Abs(v.Y-ey) > 1e-4 || v.Z != 7 {
+		t.Errorf("%v x %v transformed to %v, expected %v x %v", x, y, v, ex, ey)
+	}
+}
@@ -0,0 +1,68 @@
+// Package history records changes so they can be undone and redone.
+package history
+
+// Max is the number of changes that can be undone
+const Max = 100
+
+// Command is a change that can be undone
+type Command interface {
+	Do()
+	Undo()
+}
+
+// Merger is implemented by commands that absorb the next command, so typing
+// a number into an edit field is undone in one step instead of per key
+type Merger interface {
+	Merge(next Command) bool
+}
+
+// History holds the changes made, most recent last
+type History struct {
+	undo  []Command
+	redo  []Command
+	fresh bool // the last change was recorded rather than undone or redone
+}
+
+// Record adds a command that has already been applied
+func (h *History) Record(c Command) {
+	h.redo = nil
+	if n := len(h.undo); n > 0 && h.fresh {
+		if m, ok := h.undo[n-1].(Merger); ok && m.Merge(c) {
+			return
+		}
+	}
+	h.undo = append(h.undo, c)
+	if len(h.undo) > Max {
+		h.undo = h.undo[1:]
+	}
+	h.fresh = true
+}
+
+// Undo reverts the last change, returning false when there is nothing to undo
+func (h *History) Undo() bool {
+	n := len(h.undo)
+	if n == 0 {
+		return false
+	}
+	c := h.undo[n-1]
+	h.undo = h.undo[:n-1]
+	c.Undo()
+	h.redo = append(h.redo, c)
+	h.fresh = false
+	return true
+}
+
+// Redo applies the last undone change again, returning false when there is
+// nothing to redo
+func (h *History) Redo() bool {
+	n := len(h.redo)
+	if n == 0 {
+		return false
+	}
+	c := h.redo[n-1]
+	h.redo = h.redo[:n-1]
+	c.Do()
+	h.undo = append(h.undo, c)
+	h.fresh = false
+	return true
+}
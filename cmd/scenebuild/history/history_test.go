@@ -0,0 +1,98 @@
+package history
+
+import "testing"
+
+// set changes a value from before to after
+type set struct {
+	v      *int
+	before int
+	after  int
+	typed  bool // merges with the next typed change
+}
+
+func (c *set) Do()   { *c.v = c.after }
+func (c *set) Undo() { *c.v = c.before }
+
+func (c *set) Merge(next Command) bool {
+	n, ok := next.(*set)
+	if !ok || !c.typed || !n.typed {
+		return false
+	}
+	c.after = n.after
+	return true
+}
+
+// change applies and records a change of v to after
+func change(h *History, v *int, after int, typed bool) {
+	c := &set{v: v, before: *v, after: after, typed: typed}
+	c.Do()
+	h.Record(c)
+}
+
+func TestUndoRedo(t *testing.T) {
+	var h History
+	v := 0
+	change(&h, &v, 1, false)
+	change(&h, &v, 2, false)
+	if !h.Undo() || v != 1 || !h.Undo() || v != 0 || h.Undo() {
+		t.Errorf("Undid to %v, expected 1 then 0 then nothing left", v)
+	}
+	if !h.Redo() || v != 1 || !h.Redo() || v != 2 || h.Redo() {
+		t.Errorf("Redid to %v, expected 1 then 2 then nothing left", v)
+	}
+}
+
+func TestRecordClearsRedo(t *testing.T) {
+	var h History
+	v := 0
+	change(&h, &v, 1, false)
+	h.Undo()
+	change(&h, &v, 5, false)
+	if h.Redo() {
+		t.Errorf("Redid to %v after a new change, expected nothing to redo", v)
+	}
+	if !h.Undo() || v != 0 {
+		t.Errorf("Undid to %v, expected 0", v)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	var h History
+	v := 0
+	// typing 1, 12, 123 is one step
+	change(&h, &v, 1, true)
+	change(&h, &v, 12, true)
+	change(&h, &v, 123, true)
+	if !h.Undo() || v != 0 || h.Undo() {
+		t.Errorf("Undid typing to %v, expected 0 in one step", v)
+	}
+
+	// a typed change after an undo starts a new step
+	h.Redo()
+	change(&h, &v, 4, true)
+	if !h.Undo() || v != 123 {
+		t.Errorf("Undid to %v, expected the redone 123 to be kept", v)
+	}
+
+	// changes that don't merge stay separate
+	change(&h, &v, 5, false)
+	change(&h, &v, 6, true)
+	if !h.Undo() || v != 5 {
+		t.Errorf("Undid to %v, expected 5", v)
+	}
+}
+
+func TestMax(t *testing.T) {
+	var h History
+	v := 0
+	for iC := 1; iC <= Max+10; iC++ {
+		change(&h, &v, iC, false)
+	}
+	undone := 0
+	for h.Undo() {
+		undone++
+	}
+	if undone != Max || v != 10 {
+		t.Errorf("Undid %v changes to %v, expected %v back to 10", undone, v, Max)
+	}
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/tgreiser/cymapper/correct"
+	"github.com/tgreiser/cymapper/layout"
+	"github.com/tgreiser/cymapper/output"
+)
+
+var leds = flag.Int("leds", 460, "Number of LEDs per strip (1-10000)")
+var pins = flag.Int("pins", 8, "Number of pins which have LEDs connected")
+var brightness = flag.Int("brightness", 64, "LED brightness (1-255)")
+var pattern = flag.String("pattern", "chase", "Test to run: chase, solid, blink or walk")
+var pin = flag.Int("pin", 0, "Only test this pin, from 1, 0 for every pin")
+var delayMs = flag.Int("delay-ms", 50, "Number of milliseconds each step of chase and walk is shown")
+var hold = flag.Float64("hold", 2, "Seconds each colour of solid is shown")
+var loop = flag.Bool("loop", true, "Repeat the test until interrupted")
+
+// -output, -layout and the colour corrections, shared with play and effects.
+// -layout overrides -pins and -leds.
+var outFlags = output.AddFlags(flag.CommandLine)
+
+// wiring of the LEDs, from -layout or -pins strips of -leds RGB LEDs
+var lay *layout.Layout
+
+// blink code timing
+const (
+	blinkOn  = 300 * time.Millisecond
+	blinkOff = 300 * time.Millisecond
+	blinkGap = 1500 * time.Millisecond
+)
+
+// pin colours for chase, so strips that are swapped stand out
+var pinColors = [][3]float64{
+	{1, 0, 0}, {0, 1, 0}, {0, 0, 1}, {1, 1, 0}, {0, 1, 1}, {1, 0, 1}, {1, 1, 1}, {1, 0.4, 0},
+}
+
+// cs receives the interrupt that ends the test
+var cs = make(chan os.Signal, 1)
+
+var out output.Output
+
+func init() {
+	flag.Parse()
+	if *leds < 1 || *pins < 1 {
+		log.Fatalf("-leds and -pins must be at least 1\n")
+	}
+	var err error
+	if lay, err = outFlags.Wiring(layout.Uniform(*pins, *leds)); err != nil {
+		log.Fatalf("Bad layout %v: %v\n", *outFlags.Layout, err)
+	}
+	if *pin < 0 || *pin > len(lay.Strips) {
+		log.Fatalf("-pin must be between 0 and %v\n", len(lay.Strips))
+	}
+}
+
+/**
+ * Check the wiring before mapping: strip order, colour order and dead pixels
+ */
+func main() {
+	var test func() bool
+	switch *pattern {
+	case "chase":
+		test = chase
+	case "solid":
+		test = solid
+	case "blink":
+		test = blink
+	case "walk":
+		test = walk
+	default:
+		log.Fatalf("Unknown pattern %v, use chase, solid, blink or walk\n", *pattern)
+	}
+
+	// colours are sent as they are, less -white and the -max-amps limit, as
+	// solid white on every pin can draw more than the supply gives
+	var err error
+	out, err = outFlags.Open(lay, correct.NewPipeline(1, 1), nil)
+	if err != nil {
+		log.Fatalf("Unable to open output: %v\n", err)
+	}
+	defer out.Close()
+	// leave the LEDs off
+	defer out.Write(newFrame())
+
+	signal.Notify(cs, os.Interrupt)
+	fmt.Printf("Running %v on %v, ctrl-c to stop\n", *pattern, lay)
+	for test() && *loop {
+		// again until interrupted
+	}
+}
+
+// testPins returns the pins under test, from 0
+func testPins() []int {
+	if *pin > 0 {
+		return []int{*pin - 1}
+	}
+	ps := []int{}
+	for iP := range lay.Strips {
+		ps = append(ps, iP)
+	}
+	return ps
+}
+
+// newFrame returns every LED off, RGB in address order
+func newFrame() []byte {
+	return make([]byte, lay.Total()*3)
+}
+
+// show sends frame and holds it for d, it returns false when interrupted
+func show(frame []byte, d time.Duration) bool {
+	if err := out.Write(frame); err != nil {
+		log.Fatalf("Output write error: %v\n", err)
+	}
+	select {
+	case <-cs:
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
+
+// set colours LED iL of pin iP, c is 0-1 of -brightness for each of red,
+// green and blue
+func set(frame []byte, iP, iL int, c [3]float64) {
+	at := lay.Address(iP, iL) * 3
+	for iC := 0; iC < 3; iC++ {
+		frame[at+iC] = byte(c[iC] * float64(*brightness))
+	}
+}
+
+// chase runs one LED down every strip at once, coloured by pin
+func chase() bool {
+	delay := time.Duration(*delayMs) * time.Millisecond
+	longest := 0
+	for _, iP := range testPins() {
+		if lay.Strips[iP].Length > longest {
+			longest = lay.Strips[iP].Length
+		}
+	}
+	for iL := 0; iL < longest; iL++ {
+		frame := newFrame()
+		for _, iP := range testPins() {
+			if iL < lay.Strips[iP].Length {
+				set(frame, iP, iL, pinColors[iP%len(pinColors)])
+			}
+		}
+		if !show(frame, delay) {
+			return false
+		}
+	}
+	return true
+}
+
+// solid lights every LED red, green, blue then white. A strip with the
+// wrong colour order shows the wrong colour.
+func solid() bool {
+	for _, c := range []struct {
+		name string
+		rgb  [3]float64
+	}{
+		{"red", [3]float64{1, 0, 0}},
+		{"green", [3]float64{0, 1, 0}},
+		{"blue", [3]float64{0, 0, 1}},
+		{"white", [3]float64{1, 1, 1}},
+	} {
+		fmt.Printf("All %v\n", c.name)
+		frame := newFrame()
+		for _, iP := range testPins() {
+			for iL := 0; iL < lay.Strips[iP].Length; iL++ {
+				set(frame, iP, iL, c.rgb)
+			}
+		}
+		if !show(frame, time.Duration(*hold*float64(time.Second))) {
+			return false
+		}
+	}
+	return true
+}
+
+// blink flashes pin N N times, so each strip can be matched to its pin
+func blink() bool {
+	ps := testPins()
+	most := ps[len(ps)-1] + 1
+	for iB := 0; iB < most; iB++ {
+		frame := newFrame()
+		for _, iP := range ps {
+			if iB > iP {
+				continue
+			}
+			for iL := 0; iL < lay.Strips[iP].Length; iL++ {
+				set(frame, iP, iL, [3]float64{1, 1, 1})
+			}
+		}
+		if !show(frame, blinkOn) || !show(newFrame(), blinkOff) {
+			return false
+		}
+	}
+	return show(newFrame(), blinkGap)
+}
+
+// walk lights one LED at a time along each strip in turn, a dead pixel is
+// a step where nothing lights
+func walk() bool {
+	delay := time.Duration(*delayMs) * time.Millisecond
+	for _, iP := range testPins() {
+		for iL := 0; iL < lay.Strips[iP].Length; iL++ {
+			fmt.Printf("\rPin %v LED %v ", iP+1, iL)
+			frame := newFrame()
+			set(frame, iP, iL, [3]float64{1, 1, 1})
+			if !show(frame, delay) {
+				fmt.Println()
+				return false
+			}
+		}
+		fmt.Println()
+	}
+	return true
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/tgreiser/cymapper/warp"
+)
+
+var tsvPath = flag.String("file", "output3d.tsv", "Filename for the x, y, z tsv output")
+var cameras = flag.String("cameras", "", "Comma separated calibration files with a pose, one per map, e.g. cam0.json,cam1.json")
+var undistort = flag.Bool("undistort", true, "Remove lens distortion from the maps first; turn off for maps made with cameramap -calibration")
+
+func init() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: triangulate -cameras cam0.json,cam1.json [options] map0.tsv map1.tsv\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+}
+
+/**
+ * Combine maps of the same LEDs taken from two or more posed cameras into a
+ * single map with depth
+ */
+func main() {
+	calibs := strings.Split(*cameras, ",")
+	if *cameras == "" || len(calibs) != flag.NArg() || len(calibs) < 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	fmt.Printf("\ncymapper triangulate\n")
+
+	cams := make([]*warp.Lens, len(calibs))
+	maps := make([][]warp.Point, len(calibs))
+	for iC, path := range calibs {
+		var err error
+		cams[iC], err = warp.LoadLens(path)
+		if err != nil {
+			log.Fatalf("Unable to load calibration %v: %v\n", path, err)
+		}
+		if cams[iC].Pose == nil {
+			log.Fatalf("%v has no pose, run cmd/calibrate -pose first\n", path)
+		}
+		maps[iC] = readMap(flag.Arg(iC))
+		if len(maps[iC]) != len(maps[0]) {
+			log.Fatalf("%v has %v LEDs but %v has %v, the maps must share an address order\n",
+				flag.Arg(iC), len(maps[iC]), flag.Arg(0), len(maps[0]))
+		}
+		if *undistort {
+			for iP, p := range maps[iC] {
+				maps[iC][iP] = cams[iC].Undistort(p)
+			}
+		}
+	}
+
+	file, err := os.Create(*tsvPath)
+	if err != nil {
+		log.Fatalf("Unable to create %v: %v\n", *tsvPath, err)
+	}
+	defer file.Close()
+	w := csv.NewWriter(file)
+	defer w.Flush()
+	w.Comma = '\t'
+
+	// reprojection error shows how well the cameras agree on each LED
+	var sumErr, maxErr float64
+	maxAddr := 0
+	seen := make([]warp.Point, len(cams))
+	for iP := range maps[0] {
+		for iC := range cams {
+			seen[iC] = maps[iC][iP]
+		}
+		p, err := warp.Triangulate(cams, seen)
+		if err != nil {
+			log.Fatalf("LED %v: %v\n", iP, err)
+		}
+		for iC, cam := range cams {
+			proj := cam.Project(p)
+			e := math.Hypot(proj.X-seen[iC].X, proj.Y-seen[iC].Y)
+			sumErr += e
+			if e > maxErr {
+				maxErr, maxAddr = e, iP
+			}
+		}
+		w.Write([]string{
+			strconv.FormatFloat(p.X, 'f', -1, 32),
+			strconv.FormatFloat(p.Y, 'f', -1, 32),
+			strconv.FormatFloat(p.Z, 'f', -1, 32),
+		})
+	}
+	fmt.Printf("Triangulated %v LEDs from %v cameras\n", len(maps[0]), len(cams))
+	fmt.Printf("Reprojection error: mean %.2f px, worst %.2f px at LED %v\n",
+		sumErr/float64(len(maps[0])*len(cams)), maxErr, maxAddr)
+	fmt.Printf("Writing %v\n", *tsvPath)
+}
+
+func readMap(path string) []warp.Point {
+	file, err := os.Open(path)
+	if err != nil {
+		log.Fatalf("Unable to open %v: %v\n", path, err)
+	}
+	defer file.Close()
+	pts, err := warp.ReadPoints(file)
+	if err != nil {
+		log.Fatalf("Unable to read %v: %v\n", path, err)
+	}
+	return pts
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/tgreiser/cymapper/warp"
+)
+
+var calibration = flag.String("calibration", "calibration.json", "Lens calibration file from cmd/calibrate")
+
+func init() {
+	flag.Parse()
+}
+
+/**
+ * Read camera TSV data from stdin, remove the lens distortion measured by
+ * cmd/calibrate and write the result to stdout
+ */
+func main() {
+	lens, err := warp.LoadLens(*calibration)
+	if err != nil {
+		log.Fatalf("Unable to load calibration %v: %v\n", *calibration, err)
+	}
+	pts, err := warp.ReadPoints(os.Stdin)
+	if err != nil {
+		log.Fatalf("Unable to read map: %v", err)
+	}
+	fmt.Fprintf(os.Stderr, "\ncymapper undistort\n")
+	fmt.Fprintf(os.Stderr, "Lens %v x %v, RMS error %v px\n", lens.Width, lens.Height, lens.Error)
+
+	for iP, p := range pts {
+		pts[iP] = lens.Undistort(p)
+	}
+	if err := warp.WritePoints(os.Stdout, pts); err != nil {
+		log.Fatalf("Can not write TSV data: %v", err)
+	}
+}
@@ -0,0 +1,52 @@
+package correct
+
+import "testing"
+
+func TestPipelineLevels(t *testing.T) {
+	wide := NewPipeline(2, 0.5).Apply([]byte{0, 128, 255}, nil)
+	// (128/255)^2 is about a quarter, then halved
+	if wide[0] != 0 || wide[1] != 8256 || wide[2] != 32768 {
+		t.Errorf("Corrected %v, expected [0 8256 32768]", wide)
+	}
+
+	wide = NewPipeline(2, 1).Apply([]byte{0, 1, 2, 255, 255, 255}, nil)
+	// 1 and 2 both round to 0 at 8 bits but stay apart at 16
+	if wide[0] != 0 || wide[1] == 0 || wide[2] <= wide[1] || wide[3] != 65535 {
+		t.Errorf("Corrected %v", wide)
+	}
+}
+
+func TestPipeline(t *testing.T) {
+	p := NewPipeline(1, 1)
+	p.SetWhite(1, 1, [3]float64{1, 0.5, 0})
+	wide := p.Apply([]byte{255, 255, 255, 255, 255, 255, 255, 255, 255}, nil)
+	want := []uint16{65535, 65535, 65535, 65535, 32768, 0, 65535, 65535, 65535}
+	for iX := range want {
+		if wide[iX] != want[iX] {
+			t.Fatalf("Corrected %v, expected %v", wide, want)
+		}
+	}
+
+	// 100 white LEDs at 60mA each want 6A
+	p.Limiter = &Limiter{Amps: 3.1, ChannelMilliamps: 20, IdleMilliamps: 1}
+	frame := make([]byte, 300)
+	for iX := range frame {
+		frame[iX] = 255
+	}
+	wide = p.Apply(frame, wide)
+	if a := p.Limiter.Current(wide); a > 3.1 || a < 3.09 {
+		t.Errorf("Limited to %v amps, expected 3.1", a)
+	}
+}
+
+func TestTemperature(t *testing.T) {
+	if w := Temperature(Neutral); w != [3]float64{1, 1, 1} {
+		t.Errorf("Neutral white is %v", w)
+	}
+	if w := Temperature(3000); w[0] != 1 || w[2] > w[1] || w[1] > 1 {
+		t.Errorf("Warm white is %v, expected less blue than green than red", w)
+	}
+	if _, err := ParseWhite("1:0.9"); err == nil {
+		t.Error("Two scales were accepted")
+	}
+}
@@ -0,0 +1,195 @@
+// Package correct adjusts frames of LED colours before they are sent, so the
+// LEDs show what the video intended.
+package correct
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Neutral is the colour temperature that leaves colours unchanged
+const Neutral = 6600
+
+// Pipeline corrects frames for one output: the gamma curve and brightness,
+// the white point of each fixture and a limit on the current drawn
+type Pipeline struct {
+	gamma, brightness float64
+	curves            *curves // Addresses outside every fixture
+	fixtures          []fixture
+	Limiter           *Limiter // nil for no limit
+}
+
+// curves map 8 bit input to 16 bit output for red, green and blue
+type curves [3][256]uint16
+
+// fixture is a run of addresses with their own white point
+type fixture struct {
+	start, count int
+	curves       *curves
+}
+
+// NewPipeline corrects with gamma (1 for none, 2.2 is typical) and
+// brightness (0-1). LEDs are linear, so without gamma dark video looks
+// washed out.
+func NewPipeline(gamma, brightness float64) *Pipeline {
+	p := &Pipeline{gamma: gamma, brightness: brightness}
+	p.curves = p.curve([3]float64{1, 1, 1})
+	return p
+}
+
+func (p *Pipeline) curve(white [3]float64) *curves {
+	c := &curves{}
+	for iC := range c {
+		for iV := range c[iC] {
+			v := p.brightness * white[iC] * math.Pow(float64(iV)/255, p.gamma)
+			c[iC][iV] = uint16(math.Round(math.Max(0, math.Min(1, v)) * 65535))
+		}
+	}
+	return c
+}
+
+// SetWhite scales red, green and blue of count LEDs from address start, to
+// match the white of one fixture to the others
+func (p *Pipeline) SetWhite(start, count int, white [3]float64) {
+	p.fixtures = append(p.fixtures, fixture{start, count, p.curve(white)})
+}
+
+// SetWhites reads a comma separated white point for each fixture, in the
+// form ParseWhite reads, where counts are the LEDs of each fixture in
+// address order. A single white point is used for every LED.
+func (p *Pipeline) SetWhites(spec string, counts []int) error {
+	entries := strings.Split(spec, ",")
+	if len(entries) == 1 {
+		white, err := ParseWhite(entries[0])
+		if err != nil {
+			return err
+		}
+		p.curves = p.curve(white)
+		return nil
+	}
+	if len(entries) != len(counts) {
+		return fmt.Errorf("%v white points for %v fixtures", len(entries), len(counts))
+	}
+	start := 0
+	for iF, e := range entries {
+		white, err := ParseWhite(strings.TrimSpace(e))
+		if err != nil {
+			return err
+		}
+		p.SetWhite(start, counts[iF], white)
+		start += counts[iF]
+	}
+	return nil
+}
+
+// Apply corrects frame, 8 bit RGB in address order, into dst at 16 bits per
+// channel. dst is grown to fit.
+func (p *Pipeline) Apply(frame []byte, dst []uint16) []uint16 {
+	if cap(dst) < len(frame) {
+		dst = make([]uint16, len(frame))
+	}
+	dst = dst[:len(frame)]
+	for iL := 0; iL*3+2 < len(frame); iL++ {
+		c := p.curves
+		for _, f := range p.fixtures {
+			if iL >= f.start && iL < f.start+f.count {
+				c = f.curves
+				break
+			}
+		}
+		for iC := 0; iC < 3; iC++ {
+			dst[iL*3+iC] = c[iC][frame[iL*3+iC]]
+		}
+	}
+	if p.Limiter != nil {
+		p.Limiter.Limit(dst)
+	}
+	return dst
+}
+
+// Temperature returns the red, green and blue scale of a white at kelvin,
+// relative to Neutral. Lower is warmer.
+func Temperature(kelvin float64) [3]float64 {
+	// Tanner Helland's fit to the black body colours
+	t := kelvin / 100
+	var r, g, b float64
+	if t <= 66 {
+		r = 255
+		g = 99.4708025861*math.Log(t) - 161.1195681661
+	} else {
+		r = 329.698727446 * math.Pow(t-60, -0.1332047592)
+		g = 288.1221695283 * math.Pow(t-60, -0.0755148492)
+	}
+	switch {
+	case t >= 66:
+		b = 255
+	case t <= 19:
+		b = 0
+	default:
+		b = 138.5177312231*math.Log(t-10) - 305.0447927307
+	}
+	scale := func(v float64) float64 {
+		return math.Max(0, math.Min(255, v)) / 255
+	}
+	return [3]float64{scale(r), scale(g), scale(b)}
+}
+
+// ParseWhite reads a white point as a colour temperature like 5000, or as
+// red, green and blue scales like 1:0.9:0.8
+func ParseWhite(s string) ([3]float64, error) {
+	fields := strings.Split(s, ":")
+	if len(fields) == 1 {
+		k, err := strconv.ParseFloat(s, 64)
+		if err != nil || k < 1000 || k > 40000 {
+			return [3]float64{}, fmt.Errorf("white %v must be a temperature from 1000 to 40000 or r:g:b", s)
+		}
+		return Temperature(k), nil
+	}
+	if len(fields) != 3 {
+		return [3]float64{}, fmt.Errorf("white %v must be a temperature or r:g:b", s)
+	}
+	var white [3]float64
+	for iC, f := range fields {
+		v, err := strconv.ParseFloat(f, 64)
+		if err != nil || v < 0 || v > 1 {
+			return [3]float64{}, fmt.Errorf("white %v scales must be 0-1", s)
+		}
+		white[iC] = v
+	}
+	return white, nil
+}
+
+// Limiter keeps the current drawn by the LEDs under what the power supply
+// can deliver, by dimming whole frames
+type Limiter struct {
+	Amps             float64 // Most current to draw
+	ChannelMilliamps float64 // Current of one colour channel at full, about 20 for WS2812
+	IdleMilliamps    float64 // Current of each LED when it is off, about 1
+}
+
+// Current returns the amps a 16 bit RGB frame draws
+func (l *Limiter) Current(frame []uint16) float64 {
+	sum := 0.0
+	for _, v := range frame {
+		sum += float64(v)
+	}
+	return (sum/65535*l.ChannelMilliamps + float64(len(frame)/3)*l.IdleMilliamps) / 1000
+}
+
+// Limit dims frame in place so it draws at most Amps, and returns the scale
+// used, 1 when the frame was under the limit
+func (l *Limiter) Limit(frame []uint16) float64 {
+	idle := float64(len(frame)/3) * l.IdleMilliamps / 1000
+	lit := l.Current(frame) - idle
+	if lit <= 0 || idle+lit <= l.Amps {
+		return 1
+	}
+	// idle current is drawn however dim the frame is
+	scale := math.Max(0, l.Amps-idle) / lit
+	for iX, v := range frame {
+		frame[iX] = uint16(float64(v) * scale)
+	}
+	return scale
+}
@@ -0,0 +1,90 @@
+// Package detect finds lit LEDs in camera frames.
+package detect
+
+import (
+	"image"
+	"image/color"
+	"sort"
+
+	"gocv.io/x/gocv"
+)
+
+// Spot is a bright area of a camera frame
+type Spot struct {
+	image.Point
+	Value float32 // Brightness after blurring, 0-255
+}
+
+// Detector blurs frames to reduce noise before looking for bright spots. It
+// keeps its working images between frames, call Close when done.
+type Detector struct {
+	radius int
+	gray   gocv.Mat
+	mask   gocv.Mat
+}
+
+// NewDetector returns a detector using a gaussian blur of the given radius
+func NewDetector(radius int) *Detector {
+	// ensure radius is above 0 and an odd number
+	if radius < 1 {
+		radius = 1
+	}
+	if radius%2 == 0 {
+		radius = radius + 1
+	}
+	return &Detector{radius: radius, gray: gocv.NewMat(), mask: gocv.NewMat()}
+}
+
+func (d *Detector) Close() {
+	d.gray.Close()
+	d.mask.Close()
+}
+
+// blur leaves a blurred gray scale copy of img in d.gray
+func (d *Detector) blur(img gocv.Mat) {
+	gocv.CvtColor(img, &d.gray, gocv.ColorRGBToGray)
+	gocv.GaussianBlur(d.gray, &d.gray, image.Point{X: d.radius, Y: d.radius}, 0, 0, gocv.BorderDefault)
+}
+
+// Brightest returns the brightest spot in img, which is the lit LED when
+// mapping one LED at a time
+func (d *Detector) Brightest(img gocv.Mat) Spot {
+	d.blur(img)
+	_, maxVal, _, maxLoc := gocv.MinMaxLoc(d.gray)
+	return Spot{Point: maxLoc, Value: maxVal}
+}
+
+// Spots returns the centers of the areas of img brighter than threshold,
+// brightest first. At most limit spots are returned, 0 for no limit.
+func (d *Detector) Spots(img gocv.Mat, threshold float32, limit int) []Spot {
+	d.blur(img)
+	gocv.Threshold(d.gray, &d.mask, threshold, 255, gocv.ThresholdBinary)
+	contours := gocv.FindContours(d.mask, gocv.RetrievalExternal, gocv.ChainApproxSimple)
+	defer contours.Close()
+
+	spots := make([]Spot, 0, contours.Size())
+	for iC := 0; iC < contours.Size(); iC++ {
+		r := gocv.BoundingRect(contours.At(iC))
+		c := image.Point{X: (r.Min.X + r.Max.X) / 2, Y: (r.Min.Y + r.Max.Y) / 2}
+		spots = append(spots, Spot{Point: c, Value: float32(d.gray.GetUCharAt(c.Y, c.X))})
+	}
+	sort.Slice(spots, func(i, j int) bool {
+		return spots[i].Value > spots[j].Value
+	})
+	if limit > 0 && len(spots) > limit {
+		spots = spots[:limit]
+	}
+	return spots
+}
+
+// Mark draws a box around a detected point
+func Mark(img *gocv.Mat, pt image.Point, c color.RGBA) {
+	gocv.Rectangle(img, image.Rect(pt.X-6, pt.Y-6, pt.X+6, pt.Y+6), c, 3)
+}
+
+// Crosshair draws lines through the center of img, for lining up the camera
+func Crosshair(img *gocv.Mat, c color.RGBA) {
+	w, h := img.Cols(), img.Rows()
+	gocv.Line(img, image.Point{X: w / 2, Y: 0}, image.Point{X: w / 2, Y: h}, c, 1)
+	gocv.Line(img, image.Point{X: 0, Y: h / 2}, image.Point{X: w, Y: h / 2}, c, 1)
+}
@@ -0,0 +1,55 @@
+// Package effects colours LEDs from their positions in a scene. Patterns are
+// sampled at each LED, with positions normalised to 0-1 across the scene and
+// y running down like an image. Z is 0 for flat scenes.
+package effects
+
+import (
+	"image/color"
+	"math"
+
+	hsl "github.com/gerow/go-color"
+)
+
+// Point is the position of an LED, 0-1 across the scene
+type Point struct {
+	X, Y, Z float64
+}
+
+// Pattern returns the colour at a point t seconds after the pattern started
+type Pattern interface {
+	At(p Point, t float64) color.RGBA
+}
+
+// Frame fills frame with the pattern colour of each point, 3 bytes per LED in
+// address order. LEDs past the last point are turned off. The frame is grown
+// to hold at least every point.
+func Frame(pat Pattern, pts []Point, t float64, frame []byte) []byte {
+	if len(frame) < len(pts)*3 {
+		frame = make([]byte, len(pts)*3)
+	}
+	for iP, p := range pts {
+		c := pat.At(p, t)
+		frame[iP*3] = c.R
+		frame[iP*3+1] = c.G
+		frame[iP*3+2] = c.B
+	}
+	for iX := len(pts) * 3; iX < len(frame); iX++ {
+		frame[iX] = 0
+	}
+	return frame
+}
+
+// blend mixes a and b, f is 0 for all a and 1 for all b
+func blend(a, b color.RGBA, f float64) color.RGBA {
+	mix := func(x, y uint8) uint8 {
+		return uint8(math.Round(float64(x)*(1-f) + float64(y)*f))
+	}
+	return color.RGBA{mix(a.R, b.R), mix(a.G, b.G), mix(a.B, b.B), 255}
+}
+
+// hue returns a fully saturated colour, hue wraps around 0-1
+func hue(h float64) color.RGBA {
+	h = h - math.Floor(h)
+	rgb := hsl.HSL{H: h, S: 1.0, L: 0.5}.ToRGB()
+	return color.RGBA{uint8(rgb.R * 255), uint8(rgb.G * 255), uint8(rgb.B * 255), 255}
+}
@@ -0,0 +1,115 @@
+package effects
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestFrame(t *testing.T) {
+	bar := Bar{Width: 0.25, Speed: 0.5, Color: color.RGBA{255, 0, 0, 255}}
+	pts := []Point{{0.1, 0.5, 0}, {0.4, 0.5, 0}, {0.6, 0.5, 0}}
+	// after one second the bar runs from 0.25 to 0.5
+	frame := Frame(bar, pts, 1, make([]byte, 12))
+	want := []byte{0, 0, 0, 255, 0, 0, 0, 0, 0, 0, 0, 0}
+	for iX := range want {
+		if frame[iX] != want[iX] {
+			t.Fatalf("Frame %v, expected %v", frame, want)
+		}
+	}
+}
+
+func TestImage(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	img.Set(1, 1, color.RGBA{0, 0, 255, 255})
+	pat := NewImage(img)
+	if c := pat.At(Point{0.9, 1, 0}, 0); c.B != 255 {
+		t.Errorf("Bottom right was %v, expected blue", c)
+	}
+	if c := pat.At(Point{0.2, 0.2, 0}, 0); c.B != 0 {
+		t.Errorf("Top left was %v, expected black", c)
+	}
+}
+
+func TestPlaylist(t *testing.T) {
+	red, blue := color.RGBA{255, 0, 0, 255}, color.RGBA{0, 0, 255, 255}
+	pl := &Playlist{
+		Entries: []Entry{
+			{Pattern: Bar{Width: 1, Color: red}, Duration: 10},
+			{Pattern: Bar{Width: 1, Color: blue}, Duration: 5},
+		},
+		Fade: 2,
+	}
+	for _, tc := range []struct {
+		t    float64
+		want color.RGBA
+	}{
+		{1, red},
+		{9, color.RGBA{128, 0, 128, 255}},
+		{12, blue},
+		{16, red}, // starts over
+	} {
+		if c := pl.At(Point{0.5, 0.5, 0}, tc.t); c != tc.want {
+			t.Errorf("At %v was %v, expected %v", tc.t, c, tc.want)
+		}
+	}
+}
+
+func TestParseEntry(t *testing.T) {
+	e, err := ParseEntry("fire:speed=0.6,duration=60", 30, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f, ok := e.Pattern.(Fire); !ok || f.Speed != 0.6 || f.Scale != 4 || e.Duration != 60 {
+		t.Errorf("Parsed %+v, expected fire at speed 0.6 for 60s", e)
+	}
+	if _, err := ParseEntry("fire:colour=1", 30, nil); err == nil {
+		t.Error("Unknown setting was accepted")
+	}
+	if _, err := ParseEntry("lava", 30, nil); err == nil {
+		t.Error("Unknown pattern was accepted")
+	}
+}
+
+func TestNoise(t *testing.T) {
+	for iX := 0; iX < 100; iX++ {
+		x := float64(iX) * 0.37
+		if v := noise(x, x*0.5, -x, 1); v < 0 || v > 1 {
+			t.Fatalf("Noise at %v was %v, expected 0-1", x, v)
+		}
+	}
+	if noise(1, 2, 3, 4) != noise(1, 2, 3, 4) {
+		t.Error("Noise is not repeatable")
+	}
+}
+
+func TestSound(t *testing.T) {
+	s := &Sound{}
+	s.Record(0, Level{Bass: 1, Beat: 1})
+	s.Record(1, Level{Bass: 0.5})
+	s.Record(2, Level{Bass: 0.25})
+	if l := s.Ago(1.5); l.Bass != 1 {
+		t.Errorf("Bass 1.5s ago was %v, expected 1", l.Bass)
+	}
+	if l := s.Ago(0); l.Bass != 0.25 || l.Beat > 0.01 {
+		t.Errorf("Latest sound was %+v, expected bass 0.25 with the beat faded", l)
+	}
+
+	// the bass from one second ago has reached half a scene from the centre
+	r := Ripple{Sound: s, Speed: 0.5, CX: 0.5, CY: 0.5, Hue: 0}
+	near, far := r.At(Point{0.5, 0.5, 0}, 0), r.At(Point{1, 0.5, 0}, 0)
+	if near.R >= far.R {
+		t.Errorf("Centre %v is brighter than the ring %v", near, far)
+	}
+
+	if _, err := ParseEntry("plasma:bass=1", 30, nil); err == nil {
+		t.Error("Reacting without sound was accepted")
+	}
+	e, err := ParseEntry("plasma:bass=1", 30, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := e.Pattern.(React); !ok {
+		t.Errorf("Pattern is %T, expected React", e.Pattern)
+	}
+}
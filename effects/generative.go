@@ -0,0 +1,132 @@
+package effects
+
+import (
+	"image/color"
+	"math"
+)
+
+// Plasma is overlapping sine waves coloured through the rainbow
+type Plasma struct {
+	Speed float64 // Rate the waves move, about cycles per second
+	Scale float64 // Waves across the scene
+}
+
+func (pl Plasma) At(p Point, t float64) color.RGBA {
+	x, y, z, tt := p.X*pl.Scale*2*math.Pi, p.Y*pl.Scale*2*math.Pi, p.Z*pl.Scale*2*math.Pi, pl.Speed*t*2*math.Pi
+	v := math.Sin(x+tt) +
+		math.Sin((y+tt)/2) +
+		math.Sin((x+y+z+tt)/2) +
+		math.Sin(math.Hypot(x-math.Pi, y-math.Pi)+tt)
+	// v is -4 to 4
+	return hue(v/8 + 0.5)
+}
+
+// Rainbow is a rainbow wave travelling across the scene at an angle
+type Rainbow struct {
+	Speed float64 // Waves per second
+	Scale float64 // Waves across the scene
+	Angle float64 // Direction of travel in degrees, 0 is left to right, 90 is top to bottom
+}
+
+func (r Rainbow) At(p Point, t float64) color.RGBA {
+	a := r.Angle * math.Pi / 180
+	pos := p.X*math.Cos(a) + p.Y*math.Sin(a)
+	return hue(pos*r.Scale - r.Speed*t)
+}
+
+// Pulse is rings spreading out from a centre, changing colour with each ring
+type Pulse struct {
+	Speed      float64 // Rings per second
+	Width      float64 // Width of a ring, 0-1 of the gap between rings
+	Scale      float64 // Rings across the scene
+	CX, CY, CZ float64 // Centre of the rings, 0-1 of the scene
+}
+
+func (pu Pulse) At(p Point, t float64) color.RGBA {
+	d := math.Sqrt((p.X-pu.CX)*(p.X-pu.CX)+(p.Y-pu.CY)*(p.Y-pu.CY)+(p.Z-pu.CZ)*(p.Z-pu.CZ)) * pu.Scale
+	phase := pu.Speed*t - d
+	ring := math.Floor(phase)
+	// fade from the leading edge of each ring
+	f := phase - ring
+	if f > pu.Width || pu.Width <= 0 {
+		return color.RGBA{}
+	}
+	return blend(hue(ring*0.13), color.RGBA{}, f/pu.Width)
+}
+
+// Noise is smooth random colour that drifts over time
+type Noise struct {
+	Speed float64 // Rate of change
+	Scale float64 // Blobs across the scene
+}
+
+func (n Noise) At(p Point, t float64) color.RGBA {
+	v := noise(p.X*n.Scale, p.Y*n.Scale, p.Z*n.Scale, n.Speed*t)
+	return hue(v * 2)
+}
+
+// Fire is flames rising from the bottom of the scene
+type Fire struct {
+	Speed float64 // Rate the flames rise, scene heights per second
+	Scale float64 // Flames across the scene
+}
+
+func (f Fire) At(p Point, t float64) color.RGBA {
+	// sampling further down over time moves the flames up
+	n := noise(p.X*f.Scale, (p.Y+f.Speed*t)*f.Scale, p.Z*f.Scale, t*0.5)
+	heat := p.Y*p.Y*1.6 - (1-n)*0.6
+	return heatColor(heat)
+}
+
+// heatColor runs from black through red and yellow to white as heat goes 0-1
+func heatColor(h float64) color.RGBA {
+	h = math.Max(0, math.Min(1, h)) * 3
+	switch {
+	case h < 1:
+		return color.RGBA{uint8(h * 255), 0, 0, 255}
+	case h < 2:
+		return color.RGBA{255, uint8((h - 1) * 255), 0, 255}
+	}
+	return color.RGBA{255, 255, uint8((h - 2) * 255), 255}
+}
+
+// noise is smooth value noise in four dimensions, 0-1
+func noise(x, y, z, w float64) float64 {
+	p := [4]float64{x, y, z, w}
+	var cell [4]int64
+	var frac [4]float64
+	for iD, v := range p {
+		fl := math.Floor(v)
+		cell[iD] = int64(fl)
+		f := v - fl
+		// smoothstep hides the grid
+		frac[iD] = f * f * (3 - 2*f)
+	}
+	// interpolate the 16 corners of the cell one dimension at a time
+	var corners [16]float64
+	for iC := range corners {
+		var c [4]int64
+		for iD := range c {
+			c[iD] = cell[iD] + int64(iC>>iD&1)
+		}
+		corners[iC] = lattice(c)
+	}
+	for iD, n := 0, 16; iD < 4; iD, n = iD+1, n/2 {
+		for iC := 0; iC < n/2; iC++ {
+			a, b := corners[iC*2], corners[iC*2+1]
+			corners[iC] = a + (b-a)*frac[iD]
+		}
+	}
+	return corners[0]
+}
+
+// lattice is a repeatable random value 0-1 for a grid point
+func lattice(c [4]int64) float64 {
+	h := uint64(0x9e3779b97f4a7c15)
+	for _, v := range c {
+		h ^= uint64(v)
+		h *= 0xbf58476d1ce4e5b9
+		h ^= h >> 31
+	}
+	return float64(h>>11) / float64(1<<53)
+}
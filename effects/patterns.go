@@ -0,0 +1,61 @@
+package effects
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// Sweep is a rainbow gradient across the scene that scrolls along X
+type Sweep struct {
+	Speed float64 // Scene widths per second
+}
+
+func (s Sweep) At(p Point, t float64) color.RGBA {
+	return hue(p.X - s.Speed*t)
+}
+
+// Bar is a solid bar moving across the scene, one LED column or row at a
+// time makes it easy to spot a fixture that is placed or flipped wrong
+type Bar struct {
+	Width    float64 // Width of the bar, 0-1 of the scene
+	Speed    float64 // Scene widths per second
+	Color    color.RGBA
+	Vertical bool // Move top to bottom instead of left to right
+}
+
+func (b Bar) At(p Point, t float64) color.RGBA {
+	pos := p.X
+	if b.Vertical {
+		pos = p.Y
+	}
+	// the bar wraps around, entering again from the start
+	head := b.Speed*t - math.Floor(b.Speed*t)
+	if d := head - pos; (d >= 0 && d < b.Width) || d+1 < b.Width {
+		return b.Color
+	}
+	return color.RGBA{}
+}
+
+// Image samples a still image stretched across the scene
+type Image struct {
+	img image.Image
+}
+
+func NewImage(img image.Image) *Image {
+	return &Image{img: img}
+}
+
+func (i *Image) At(p Point, t float64) color.RGBA {
+	b := i.img.Bounds()
+	x := b.Min.X + int(p.X*float64(b.Dx()))
+	y := b.Min.Y + int(p.Y*float64(b.Dy()))
+	if x >= b.Max.X {
+		x = b.Max.X - 1
+	}
+	if y >= b.Max.Y {
+		y = b.Max.Y - 1
+	}
+	r, g, bl, _ := i.img.At(x, y).RGBA()
+	return color.RGBA{uint8(r >> 8), uint8(g >> 8), uint8(bl >> 8), 255}
+}
@@ -0,0 +1,199 @@
+package effects
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Entry is one pattern of a playlist
+type Entry struct {
+	Name     string
+	Pattern  Pattern
+	Duration float64 // Seconds the pattern plays, including the fade to the next
+}
+
+// Playlist plays its entries in turn and starts over after the last one.
+// Each entry crossfades into the next over its last Fade seconds.
+type Playlist struct {
+	Entries []Entry
+	Fade    float64
+}
+
+func (pl *Playlist) At(p Point, t float64) color.RGBA {
+	iE, start := pl.Current(t)
+	e := pl.Entries[iE]
+	local := t - start
+	fade := math.Min(pl.Fade, e.Duration)
+	c := e.Pattern.At(p, local)
+	if len(pl.Entries) < 2 || local < e.Duration-fade {
+		return c
+	}
+	// the next pattern runs up to its start time during the fade, so it carries
+	// on smoothly once it is playing alone
+	into := local - (e.Duration - fade)
+	next := pl.Entries[(iE+1)%len(pl.Entries)]
+	return blend(c, next.Pattern.At(p, into-fade), into/fade)
+}
+
+// Current returns the index of the entry playing at t and when it started
+func (pl *Playlist) Current(t float64) (int, float64) {
+	total := 0.0
+	for _, e := range pl.Entries {
+		total += e.Duration
+	}
+	start := 0.0
+	if total > 0 {
+		start = math.Floor(t/total) * total
+	}
+	for iE, e := range pl.Entries {
+		if t < start+e.Duration {
+			return iE, start
+		}
+		start += e.Duration
+	}
+	return len(pl.Entries) - 1, start - pl.Entries[len(pl.Entries)-1].Duration
+}
+
+// params are the settings of each pattern, with their defaults
+var params = map[string]map[string]float64{
+	"plasma":  {"speed": 0.1, "scale": 1},
+	"rainbow": {"speed": 0.25, "scale": 1, "angle": 0},
+	"pulse":   {"speed": 0.5, "width": 0.3, "scale": 3, "cx": 0.5, "cy": 0.5, "cz": 0},
+	"noise":   {"speed": 0.2, "scale": 3},
+	"fire":    {"speed": 0.4, "scale": 4},
+	// these need sound
+	"ripple":   {"speed": 0.5, "cx": 0.5, "cy": 0.5, "cz": 0, "hue": 0.5},
+	"spectrum": {},
+}
+
+// inputs are the settings every pattern takes to follow the sound, each the
+// share of its brightness that input controls
+var inputs = []string{"level", "bass", "mid", "treble", "beat"}
+
+// Inputs lists the sound settings every pattern takes
+func Inputs() []string {
+	return append([]string{}, inputs...)
+}
+
+// Names lists the patterns New can build
+func Names() []string {
+	names := []string{}
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Settings lists the settings of a pattern with their defaults
+func Settings(name string) []string {
+	settings := []string{}
+	for key, def := range params[name] {
+		settings = append(settings, fmt.Sprintf("%v=%v", key, def))
+	}
+	sort.Strings(settings)
+	return settings
+}
+
+// New builds the pattern called name. Settings missing from set keep their
+// defaults. Sound is what audio-reactive patterns hear, nil when there is no
+// audio.
+func New(name string, set map[string]float64, sound *Sound) (Pattern, error) {
+	defaults, ok := params[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown pattern %v, use one of %v", name, strings.Join(Names(), ", "))
+	}
+	v := map[string]float64{}
+	for key, def := range defaults {
+		v[key] = def
+	}
+	reacts := false
+	for key, val := range set {
+		_, ok := defaults[key]
+		if isInput(key) {
+			ok, reacts = true, reacts || val != 0
+		}
+		if !ok {
+			return nil, fmt.Errorf("%v has no setting %v", name, key)
+		}
+		v[key] = val
+	}
+	if sound == nil && (reacts || name == "ripple" || name == "spectrum") {
+		return nil, fmt.Errorf("%v needs audio input", name)
+	}
+	pat := build(name, v, sound)
+	if reacts {
+		pat = React{Pattern: pat, Sound: sound,
+			Level: v["level"], Bass: v["bass"], Mid: v["mid"], Treble: v["treble"], Beat: v["beat"]}
+	}
+	return pat, nil
+}
+
+func isInput(key string) bool {
+	for _, in := range inputs {
+		if key == in {
+			return true
+		}
+	}
+	return false
+}
+
+// build makes a pattern from a complete set of settings
+func build(name string, v map[string]float64, sound *Sound) Pattern {
+	switch name {
+	case "plasma":
+		return Plasma{Speed: v["speed"], Scale: v["scale"]}
+	case "rainbow":
+		return Rainbow{Speed: v["speed"], Scale: v["scale"], Angle: v["angle"]}
+	case "pulse":
+		return Pulse{Speed: v["speed"], Width: v["width"], Scale: v["scale"], CX: v["cx"], CY: v["cy"], CZ: v["cz"]}
+	case "noise":
+		return Noise{Speed: v["speed"], Scale: v["scale"]}
+	case "ripple":
+		return Ripple{Sound: sound, Speed: v["speed"], CX: v["cx"], CY: v["cy"], CZ: v["cz"], Hue: v["hue"]}
+	case "spectrum":
+		return Spectrum{Sound: sound}
+	}
+	return Fire{Speed: v["speed"], Scale: v["scale"]}
+}
+
+// ParseEntry reads a playlist entry like "plasma" or
+// "fire:speed=0.6,scale=3,duration=60". Entries without a duration play for
+// duration seconds.
+func ParseEntry(spec string, duration float64, sound *Sound) (Entry, error) {
+	name, rest := spec, ""
+	if i := strings.Index(spec, ":"); i >= 0 {
+		name, rest = spec[:i], spec[i+1:]
+	}
+	set := map[string]float64{}
+	for _, field := range strings.Split(rest, ",") {
+		if field == "" {
+			continue
+		}
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 {
+			return Entry{}, fmt.Errorf("%v: expected setting=value", field)
+		}
+		val, err := strconv.ParseFloat(kv[1], 64)
+		if err != nil {
+			return Entry{}, fmt.Errorf("%v: %v", field, err)
+		}
+		if kv[0] == "duration" {
+			duration = val
+			continue
+		}
+		set[kv[0]] = val
+	}
+	if duration <= 0 {
+		return Entry{}, fmt.Errorf("%v: duration must be positive", spec)
+	}
+	pat, err := New(name, set, sound)
+	if err != nil {
+		return Entry{}, err
+	}
+	return Entry{Name: name, Pattern: pat, Duration: duration}, nil
+}
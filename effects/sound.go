@@ -0,0 +1,112 @@
+package effects
+
+import (
+	"image/color"
+	"math"
+	"sort"
+)
+
+// soundHistory is the seconds of sound kept for ripples to travel through
+const soundHistory = 5
+
+// beatFade is the seconds a beat takes to fade to a third
+const beatFade = 0.15
+
+// Level is one measurement of the sound, every value 0-1
+type Level struct {
+	Level, Bass, Mid, Treble float64
+	Beat                     float64 // 1 on a beat, fading until the next
+	Bands                    []float64
+}
+
+// Sound is what the audio-reactive patterns hear. The player records each
+// measurement before drawing a frame.
+type Sound struct {
+	times  []float64
+	levels []Level
+}
+
+// Record adds the sound measured at t seconds. Beat should be 1 on a beat
+// and 0 otherwise, it is faded out here.
+func (s *Sound) Record(t float64, l Level) {
+	if n := len(s.levels); n > 0 {
+		fade := s.levels[n-1].Beat * math.Exp(-(t-s.times[n-1])/beatFade)
+		l.Beat = math.Max(l.Beat, fade)
+	}
+	s.times = append(s.times, t)
+	s.levels = append(s.levels, l)
+	old := sort.SearchFloat64s(s.times, t-soundHistory)
+	if old > len(s.times)/2 {
+		// drop old sound now and then rather than on every record
+		s.times = append(s.times[:0], s.times[old:]...)
+		s.levels = append(s.levels[:0], s.levels[old:]...)
+	}
+}
+
+// Ago returns the sound heard seconds before the last measurement
+func (s *Sound) Ago(seconds float64) Level {
+	if s == nil || len(s.times) == 0 {
+		return Level{}
+	}
+	n := len(s.times)
+	i := sort.SearchFloat64s(s.times, s.times[n-1]-seconds+1e-9)
+	if i == 0 {
+		return Level{}
+	}
+	return s.levels[i-1]
+}
+
+// Ripple sends the bass outward from a centre, so each beat spreads as a ring
+type Ripple struct {
+	Sound      *Sound
+	Speed      float64 // Scene widths per second the sound travels
+	CX, CY, CZ float64 // Centre of the ripples, 0-1 of the scene
+	Hue        float64 // Colour change per scene width from the centre
+}
+
+func (r Ripple) At(p Point, t float64) color.RGBA {
+	d := math.Sqrt((p.X-r.CX)*(p.X-r.CX) + (p.Y-r.CY)*(p.Y-r.CY) + (p.Z-r.CZ)*(p.Z-r.CZ))
+	l := r.Sound.Ago(d / r.Speed)
+	v := math.Max(l.Bass*l.Bass, l.Beat)
+	return blend(color.RGBA{0, 0, 0, 255}, hue(d*r.Hue+t*0.02), v)
+}
+
+// Spectrum shows the frequency bands as bars rising from the bottom, bass on
+// the left
+type Spectrum struct {
+	Sound *Sound
+}
+
+func (sp Spectrum) At(p Point, t float64) color.RGBA {
+	bands := sp.Sound.Ago(0).Bands
+	if len(bands) == 0 {
+		return color.RGBA{}
+	}
+	iB := int(p.X * float64(len(bands)))
+	if iB >= len(bands) {
+		iB = len(bands) - 1
+	}
+	if 1-p.Y > bands[iB] {
+		return color.RGBA{}
+	}
+	return hue(float64(iB) / float64(len(bands)) * 0.8)
+}
+
+// React dims a pattern when the sound it follows is quiet. Each amount is
+// 0-1, how much of the brightness that input controls.
+type React struct {
+	Pattern
+	Sound                          *Sound
+	Level, Bass, Mid, Treble, Beat float64
+}
+
+func (r React) At(p Point, t float64) color.RGBA {
+	l := r.Sound.Ago(0)
+	gain := 1.0
+	for _, in := range [][2]float64{
+		{r.Level, l.Level}, {r.Bass, l.Bass}, {r.Mid, l.Mid}, {r.Treble, l.Treble}, {r.Beat, l.Beat},
+	} {
+		gain -= in[0] * (1 - in[1])
+	}
+	return blend(color.RGBA{0, 0, 0, 255}, r.Pattern.At(p, t), math.Max(0, gain))
+}
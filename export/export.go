@@ -0,0 +1,26 @@
+// Package export writes scene maps in the formats used by other LED tools.
+package export
+
+import (
+	"strconv"
+
+	"github.com/g3n/engine/math32"
+)
+
+// Bounds returns the smallest and largest coordinates found in pts.
+func Bounds(pts []*math32.Vector3) (min, max *math32.Vector3) {
+	if len(pts) == 0 {
+		return math32.NewVector3(0, 0, 0), math32.NewVector3(0, 0, 0)
+	}
+	min = pts[0].Clone()
+	max = pts[0].Clone()
+	for _, p := range pts {
+		min.Min(p)
+		max.Max(p)
+	}
+	return min, max
+}
+
+func formatFloat(input float32) string {
+	return strconv.FormatFloat(float64(input), 'f', -1, 32)
+}
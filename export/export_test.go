@@ -0,0 +1,77 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/g3n/engine/math32"
+)
+
+// square is the corners of an 8 x 8 square and one LED next to the second
+func square() []*math32.Vector3 {
+	return []*math32.Vector3{
+		math32.NewVector3(0, 0, 0), math32.NewVector3(8, 0, 0),
+		math32.NewVector3(0, 8, 4), math32.NewVector3(8, 8, 4),
+		math32.NewVector3(7, 1, 0),
+	}
+}
+
+func TestWritePixelblaze(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WritePixelblaze(&buf, square(), PixelblazeOptions{Normalize: true, ThreeD: true}); err != nil {
+		t.Fatal(err)
+	}
+	expected := "[[0,0,0],[1,0,0],[0,1,0.5],[1,1,0.5],[0.875,0.125,0]]\n"
+	if buf.String() != expected {
+		t.Errorf("Wrote %q, expected %q", buf.String(), expected)
+	}
+}
+
+func TestWriteFastLED(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteFastLED(&buf, square(), FastLEDOptions{Width: 3, Height: 2, Source: "square.tsv"}); err != nil {
+		t.Fatal(err)
+	}
+	// the last LED lands on the second and the middle column is empty
+	expected := `// Generated by cymapper from square.tsv
+// 5 LEDs on a 3 x 2 grid, 1 sharing a cell with a lower address
+#pragma once
+
+#include <FastLED.h>
+
+#define NUM_LEDS 5
+#define MAP_WIDTH 3
+#define MAP_HEIGHT 2
+
+// Grid position of each LED, in address order
+const uint16_t ledX[NUM_LEDS] PROGMEM = {
+  0, 2, 0, 2, 2
+};
+
+const uint16_t ledY[NUM_LEDS] PROGMEM = {
+  0, 0, 1, 1, 0
+};
+
+// LED address at each grid cell, NUM_LEDS when the cell is empty
+const uint16_t xyTable[MAP_WIDTH * MAP_HEIGHT] PROGMEM = {
+  0, 5, 1,
+  2, 5, 3
+};
+
+uint16_t XY(uint16_t x, uint16_t y) {
+  if (x >= MAP_WIDTH || y >= MAP_HEIGHT) {
+    return NUM_LEDS;
+  }
+  return pgm_read_word(&xyTable[y * MAP_WIDTH + x]);
+}
+`
+	if buf.String() != expected {
+		t.Errorf("Wrote\n%v\nexpected\n%v", buf.String(), expected)
+	}
+}
+
+func TestWriteFastLEDGrid(t *testing.T) {
+	if err := WriteFastLED(&bytes.Buffer{}, square(), FastLEDOptions{Width: 300, Height: 300}); err == nil {
+		t.Error("Expected an error for a grid over 65535 cells")
+	}
+}
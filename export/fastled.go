@@ -0,0 +1,98 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/g3n/engine/math32"
+)
+
+// FastLEDOptions controls the header written by WriteFastLED.
+type FastLEDOptions struct {
+	Width  int    // Width of the XY() grid
+	Height int    // Height of the XY() grid
+	Source string // Name of the map, written in the header comment
+}
+
+// WriteFastLED writes a C header with the grid position of every LED and an
+// XY() lookup function in the style of the FastLED XYMatrix example. Points
+// are scaled onto a Width x Height grid; when two LEDs land in the same cell
+// the lower address wins. Empty cells return NUM_LEDS, so sketches should
+// allocate one extra "safety pixel" at the end of their leds array.
+func WriteFastLED(out io.Writer, pts []*math32.Vector3, opts FastLEDOptions) error {
+	if opts.Width < 1 || opts.Height < 1 || opts.Width*opts.Height > 65535 {
+		return fmt.Errorf("invalid grid size %v x %v", opts.Width, opts.Height)
+	}
+	if len(pts) >= 65535 {
+		return fmt.Errorf("too many LEDs for a 16 bit lookup table: %v", len(pts))
+	}
+
+	min, max := Bounds(pts)
+	gx := make([]int, len(pts))
+	gy := make([]int, len(pts))
+	table := make([]int, opts.Width*opts.Height)
+	for iX := range table {
+		table[iX] = len(pts)
+	}
+	collisions := 0
+	for iP, p := range pts {
+		gx[iP] = gridPos(p.X, min.X, max.X, opts.Width)
+		gy[iP] = gridPos(p.Y, min.Y, max.Y, opts.Height)
+		cell := gy[iP]*opts.Width + gx[iP]
+		if table[cell] != len(pts) {
+			collisions++
+			continue
+		}
+		table[cell] = iP
+	}
+
+	w := bufio.NewWriter(out)
+	fmt.Fprintf(w, "// Generated by cymapper from %v\n", opts.Source)
+	fmt.Fprintf(w, "// %v LEDs on a %v x %v grid, %v sharing a cell with a lower address\n",
+		len(pts), opts.Width, opts.Height, collisions)
+	fmt.Fprintf(w, "#pragma once\n\n#include <FastLED.h>\n\n")
+	fmt.Fprintf(w, "#define NUM_LEDS %v\n#define MAP_WIDTH %v\n#define MAP_HEIGHT %v\n\n",
+		len(pts), opts.Width, opts.Height)
+
+	fmt.Fprintf(w, "// Grid position of each LED, in address order\n")
+	writeTable(w, "ledX[NUM_LEDS]", gx, 16)
+	writeTable(w, "ledY[NUM_LEDS]", gy, 16)
+
+	fmt.Fprintf(w, "// LED address at each grid cell, NUM_LEDS when the cell is empty\n")
+	writeTable(w, "xyTable[MAP_WIDTH * MAP_HEIGHT]", table, opts.Width)
+
+	fmt.Fprintf(w, `uint16_t XY(uint16_t x, uint16_t y) {
+  if (x >= MAP_WIDTH || y >= MAP_HEIGHT) {
+    return NUM_LEDS;
+  }
+  return pgm_read_word(&xyTable[y * MAP_WIDTH + x]);
+}
+`)
+	return w.Flush()
+}
+
+// gridPos scales v from min..max onto a cell index from 0 to cells-1
+func gridPos(v, min, max float32, cells int) int {
+	if max == min {
+		return 0
+	}
+	return int(math32.Round((v - min) / (max - min) * float32(cells-1)))
+}
+
+func writeTable(w *bufio.Writer, name string, values []int, perLine int) {
+	fmt.Fprintf(w, "const uint16_t %v PROGMEM = {", name)
+	for iX, v := range values {
+		if iX > 0 {
+			w.WriteString(",")
+		}
+		if iX%perLine == 0 {
+			w.WriteString("\n  ")
+		} else {
+			w.WriteString(" ")
+		}
+		w.WriteString(strconv.Itoa(v))
+	}
+	w.WriteString("\n};\n\n")
+}
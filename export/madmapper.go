@@ -0,0 +1,74 @@
+package export
+
+import (
+	"encoding/json"
+	"io"
+	"path/filepath"
+
+	"github.com/tgreiser/cymapper/cmd/scenebuild/fixture"
+)
+
+// MadMapperOptions controls the fixture file written by WriteMadMapper.
+type MadMapperOptions struct {
+	PatchOptions
+	Width  int  // Width of the mapped surface
+	Height int  // Height of the mapped surface
+	YDown  bool // Map y runs downward like the video instead of up like scenebuild
+}
+
+type madmapperFile struct {
+	Fixtures []madmapperFixture `json:"fixtures"`
+}
+
+type madmapperFixture struct {
+	Name   string           `json:"name"`
+	Type   string           `json:"type"`
+	Pixels []madmapperPixel `json:"pixels"`
+}
+
+type madmapperPixel struct {
+	Index    int     `json:"index"`
+	X        float32 `json:"x"`
+	Y        float32 `json:"y"`
+	Universe int     `json:"universe"`
+	Channel  int     `json:"channel"`
+}
+
+// WriteMadMapper writes the scene as MadMapper custom pixel fixtures, one
+// per scenebuild fixture. Pixel positions are normalised to 0..1 against the
+// Width x Height surface, which is how MadMapper places pixels on a fixture.
+// MadMapper counts y down from the top, so scenebuild scenes, which have y
+// up, are flipped unless YDown is set.
+func WriteMadMapper(out io.Writer, scene *fixture.Scene, opts MadMapperOptions) error {
+	width, height := float32(opts.Width), float32(opts.Height)
+	if width <= 0 || height <= 0 {
+		width, height = 1, 1
+	}
+
+	file := madmapperFile{}
+	patches := PatchScene(scene, opts.PatchOptions)
+	for iF, f := range scene.Fixtures() {
+		mf := madmapperFixture{Name: filepath.Base(f.Path()), Type: "RGB"}
+		if len(patches[iF]) > 0 {
+			mf.Type = pixelType(patches[iF][0])
+		}
+		for iP, p := range f.Transformed() {
+			y := p.Y
+			if !opts.YDown {
+				y = height - y
+			}
+			mf.Pixels = append(mf.Pixels, madmapperPixel{
+				Index:    iP,
+				X:        p.X / width,
+				Y:        y / height,
+				Universe: patches[iF][iP].Universe,
+				Channel:  patches[iF][iP].Channel,
+			})
+		}
+		file.Fixtures = append(file.Fixtures, mf)
+	}
+
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "\t")
+	return enc.Encode(file)
+}
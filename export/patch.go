@@ -0,0 +1,68 @@
+package export
+
+import (
+	"github.com/tgreiser/cymapper/cmd/scenebuild/fixture"
+	"github.com/tgreiser/cymapper/layout"
+)
+
+// Patch is the DMX address of a single pixel.
+type Patch struct {
+	Universe int // DMX universe
+	Channel  int // First channel of the pixel, 1-512
+	Channels int // Channels the pixel takes
+}
+
+// PatchOptions controls how pixels are assigned to universes.
+type PatchOptions struct {
+	StartUniverse    int            // Universe of the first pixel
+	ChannelsPerPixel int            // 3 for RGB
+	FixtureUniverse  bool           // Start every fixture on a new universe
+	Layout           *layout.Layout // Wiring of the pixels, each takes the channels of its strip. nil for ChannelsPerPixel
+}
+
+// PatchScene assigns every pixel of the scene, in address order, to a
+// universe and channel. Pixels never straddle two universes, so an RGB
+// universe holds 170 pixels and an RGBW one 128, the same packing as the
+// Art-Net output. The result is indexed by fixture, then pixel.
+func PatchScene(scene *fixture.Scene, opts PatchOptions) [][]Patch {
+	cpp := opts.ChannelsPerPixel
+	if cpp < 1 {
+		cpp = 3
+	}
+	universe := opts.StartUniverse
+	used := 0 // Channels of the universe taken
+	addr := 0
+
+	patches := make([][]Patch, len(scene.Fixtures()))
+	for iF, f := range scene.Fixtures() {
+		if opts.FixtureUniverse && used > 0 {
+			universe++
+			used = 0
+		}
+		patches[iF] = make([]Patch, f.Length())
+		for iP := range patches[iF] {
+			n := cpp
+			if opts.Layout != nil {
+				if pin, _ := opts.Layout.Locate(addr); pin >= 0 {
+					n = opts.Layout.Strips[pin].Bytes()
+				}
+			}
+			if used+n > layout.DMXChannels {
+				universe++
+				used = 0
+			}
+			patches[iF][iP] = Patch{Universe: universe, Channel: used + 1, Channels: n}
+			used += n
+			addr++
+		}
+	}
+	return patches
+}
+
+// pixelType is the colour space of a patched pixel, RGBW for 4 channels
+func pixelType(p Patch) string {
+	if p.Channels == 4 {
+		return "RGBW"
+	}
+	return "RGB"
+}
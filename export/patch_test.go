@@ -0,0 +1,201 @@
+package export
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tgreiser/cymapper/cmd/scenebuild/fixture"
+	"github.com/tgreiser/cymapper/layout"
+)
+
+// scene writes a map file for each length, a row of LEDs one apart, and
+// loads them as a scene
+func scene(t *testing.T, lengths ...int) *fixture.Scene {
+	fixtures := []*fixture.Fixture{}
+	for iF, n := range lengths {
+		var tsv strings.Builder
+		for iP := 0; iP < n; iP++ {
+			fmt.Fprintf(&tsv, "%v\t%v\n", iP, iF)
+		}
+		path := filepath.Join(t.TempDir(), fmt.Sprintf("fixture%v.tsv", iF))
+		if err := os.WriteFile(path, []byte(tsv.String()), 0644); err != nil {
+			t.Fatal(err)
+		}
+		fixtures = append(fixtures, fixture.NewFixture(path))
+	}
+	return fixture.NewScene(fixtures)
+}
+
+func TestPatchScene(t *testing.T) {
+	patches := PatchScene(scene(t, 200, 10), PatchOptions{StartUniverse: 1})
+	for _, c := range []struct {
+		fixture, pixel int
+		expected       Patch
+	}{
+		{0, 0, Patch{1, 1, 3}},
+		{0, 169, Patch{1, 508, 3}},
+		// 170 RGB pixels fill 510 channels, the next starts a universe
+		{0, 170, Patch{2, 1, 3}},
+		{0, 199, Patch{2, 88, 3}},
+		{1, 0, Patch{2, 91, 3}},
+	} {
+		if p := patches[c.fixture][c.pixel]; p != c.expected {
+			t.Errorf("Fixture %v pixel %v patched to %+v, expected %+v", c.fixture, c.pixel, p, c.expected)
+		}
+	}
+
+	patches = PatchScene(scene(t, 200, 10), PatchOptions{FixtureUniverse: true})
+	if p := patches[1][0]; p != (Patch{2, 1, 3}) {
+		t.Errorf("Second fixture patched to %+v, expected the start of universe 2", p)
+	}
+}
+
+func TestPatchSceneLayout(t *testing.T) {
+	lay, err := layout.Parse("150,150:RGBW")
+	if err != nil {
+		t.Fatal(err)
+	}
+	patches := PatchScene(scene(t, 300), PatchOptions{Layout: lay})
+	for _, c := range []struct {
+		pixel    int
+		expected Patch
+	}{
+		{149, Patch{0, 448, 3}},
+		// 450 channels of RGB leave room for 15 RGBW pixels
+		{150, Patch{0, 451, 4}},
+		{164, Patch{0, 507, 4}},
+		{165, Patch{1, 1, 4}},
+		// and 128 fill the next universe
+		{292, Patch{1, 509, 4}},
+		{293, Patch{2, 1, 4}},
+		{299, Patch{2, 25, 4}},
+	} {
+		if p := patches[0][c.pixel]; p != c.expected {
+			t.Errorf("Pixel %v patched to %+v, expected %+v", c.pixel, p, c.expected)
+		}
+	}
+}
+
+func TestWriteMadMapper(t *testing.T) {
+	lay, err := layout.Parse("2,1:RGBW")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	opts := MadMapperOptions{Width: 4, Height: 2, PatchOptions: PatchOptions{StartUniverse: 1, Layout: lay}}
+	if err := WriteMadMapper(&buf, scene(t, 2, 1), opts); err != nil {
+		t.Fatal(err)
+	}
+	expected := `{
+	"fixtures": [
+		{
+			"name": "fixture0.tsv",
+			"type": "RGB",
+			"pixels": [
+				{
+					"index": 0,
+					"x": 0,
+					"y": 1,
+					"universe": 1,
+					"channel": 1
+				},
+				{
+					"index": 1,
+					"x": 0.25,
+					"y": 1,
+					"universe": 1,
+					"channel": 4
+				}
+			]
+		},
+		{
+			"name": "fixture1.tsv",
+			"type": "RGBW",
+			"pixels": [
+				{
+					"index": 0,
+					"x": 0,
+					"y": 0.5,
+					"universe": 1,
+					"channel": 7
+				}
+			]
+		}
+	]
+}
+`
+	if buf.String() != expected {
+		t.Errorf("Wrote\n%v\nexpected\n%v", buf.String(), expected)
+	}
+}
+
+func TestWriteResolume(t *testing.T) {
+	var buf bytes.Buffer
+	opts := ResolumeOptions{Name: "rig", Width: 1920, Height: 1080, PatchOptions: PatchOptions{StartUniverse: 1}}
+	if err := WriteResolume(&buf, scene(t, 171), opts); err != nil {
+		t.Fatal(err)
+	}
+	state := resolumeState{}
+	if err := xml.Unmarshal(buf.Bytes(), &state); err != nil {
+		t.Fatal(err)
+	}
+	// a lumiverse for each universe the fixture spans
+	if len(state.Screens) != 2 {
+		t.Fatalf("Wrote %v screens, expected 2", len(state.Screens))
+	}
+	screen := state.Screens[1]
+	if screen.Name != "fixture0.tsv U2" || screen.Universe != 2 || len(screen.Fixtures) != 1 {
+		t.Errorf("Second screen %v on universe %v with %v pixels, expected fixture0.tsv U2 with 1",
+			screen.Name, screen.Universe, len(screen.Fixtures))
+	}
+	// y is flipped against the composition height
+	expected := resolumeFixture{Name: "fixture0.tsv 170", Channel: 1, ColorSpace: "RGB", X: "170", Y: "1080"}
+	if len(screen.Fixtures) > 0 && screen.Fixtures[0] != expected {
+		t.Errorf("Last pixel %+v, expected %+v", screen.Fixtures[0], expected)
+	}
+
+	buf.Reset()
+	opts.YDown = true
+	if err := WriteResolume(&buf, scene(t, 1), opts); err != nil {
+		t.Fatal(err)
+	}
+	state = resolumeState{}
+	if err := xml.Unmarshal(buf.Bytes(), &state); err != nil {
+		t.Fatal(err)
+	}
+	if y := state.Screens[0].Fixtures[0].Y; y != "0" {
+		t.Errorf("Y down pixel at y %v, expected 0", y)
+	}
+}
+
+func TestWriteResolumeSharedUniverse(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteResolume(&buf, scene(t, 100, 100), ResolumeOptions{Name: "rig"}); err != nil {
+		t.Fatal(err)
+	}
+	state := resolumeState{}
+	if err := xml.Unmarshal(buf.Bytes(), &state); err != nil {
+		t.Fatal(err)
+	}
+	// the second fixture starts in the universe the first ends in
+	seen := map[int]bool{}
+	pixels := 0
+	for _, screen := range state.Screens {
+		if seen[screen.Universe] {
+			t.Errorf("Universe %v has more than one lumiverse", screen.Universe)
+		}
+		seen[screen.Universe] = true
+		pixels += len(screen.Fixtures)
+	}
+	if len(state.Screens) != 2 || pixels != 200 {
+		t.Fatalf("Wrote %v pixels on %v lumiverses, expected 200 on 2", pixels, len(state.Screens))
+	}
+	if n := len(state.Screens[0].Fixtures); n != 170 {
+		t.Errorf("First lumiverse has %v pixels, expected 170", n)
+	}
+}
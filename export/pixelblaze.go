@@ -0,0 +1,46 @@
+package export
+
+import (
+	"bufio"
+	"io"
+
+	"github.com/g3n/engine/math32"
+)
+
+// PixelblazeOptions controls the pixel map written by WritePixelblaze.
+type PixelblazeOptions struct {
+	Normalize bool // Scale coordinates into 0..1, keeping the aspect ratio
+	ThreeD    bool // Write [x, y, z] instead of [x, y]
+}
+
+// WritePixelblaze writes pts as a Pixelblaze pixel map, a JSON array with one
+// coordinate array per pixel in address order.
+func WritePixelblaze(out io.Writer, pts []*math32.Vector3, opts PixelblazeOptions) error {
+	min, max := Bounds(pts)
+	size := math32.Max(max.X-min.X, max.Y-min.Y)
+	if opts.ThreeD {
+		size = math32.Max(size, max.Z-min.Z)
+	}
+	if size == 0 {
+		size = 1
+	}
+
+	w := bufio.NewWriter(out)
+	w.WriteString("[")
+	for iP, p := range pts {
+		v := p.Clone()
+		if opts.Normalize {
+			v.Sub(min).DivideScalar(size)
+		}
+		if iP > 0 {
+			w.WriteString(",")
+		}
+		w.WriteString("[" + formatFloat(v.X) + "," + formatFloat(v.Y))
+		if opts.ThreeD {
+			w.WriteString("," + formatFloat(v.Z))
+		}
+		w.WriteString("]")
+	}
+	w.WriteString("]\n")
+	return w.Flush()
+}
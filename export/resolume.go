@@ -0,0 +1,97 @@
+package export
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/tgreiser/cymapper/cmd/scenebuild/fixture"
+)
+
+// ResolumeOptions controls the Advanced Output preset written by WriteResolume.
+type ResolumeOptions struct {
+	PatchOptions
+	Name   string // Preset name
+	Width  int    // Composition width
+	Height int    // Composition height
+	YDown  bool   // Map y runs downward like the video instead of up like scenebuild
+}
+
+type resolumeState struct {
+	XMLName xml.Name         `xml:"XmlState"`
+	Name    string           `xml:"name,attr"`
+	Size    resolumeSize     `xml:"ScreenSetup>CurrentCompositionTextureSize"`
+	Screens []resolumeScreen `xml:"ScreenSetup>screens>DmxScreen"`
+}
+
+type resolumeSize struct {
+	Width  int `xml:"width,attr"`
+	Height int `xml:"height,attr"`
+}
+
+type resolumeScreen struct {
+	Name     string            `xml:"name,attr"`
+	Universe int               `xml:"OutputDevice>OutputDeviceDmx>universe"`
+	Fixtures []resolumeFixture `xml:"layers>DmxFixture"`
+}
+
+type resolumeFixture struct {
+	Name       string `xml:"name,attr"`
+	Channel    int    `xml:"startChannel,attr"`
+	ColorSpace string `xml:"colorSpace,attr"`
+	X          string `xml:"InputRect>v>x,omitempty"`
+	Y          string `xml:"InputRect>v>y,omitempty"`
+}
+
+// WriteResolume writes the scene as a Resolume Arena Advanced Output preset.
+// Each universe becomes one DMX lumiverse, named after the first fixture
+// patched to it, holding one single-pixel fixture per LED that samples the
+// composition at the LED's transformed position. Scenebuild scenes have y
+// up, so y is flipped against Height unless YDown is set.
+func WriteResolume(out io.Writer, scene *fixture.Scene, opts ResolumeOptions) error {
+	state := resolumeState{
+		Name: opts.Name,
+		Size: resolumeSize{Width: opts.Width, Height: opts.Height},
+	}
+
+	// fixtures share a universe unless FixtureUniverse is set, and two
+	// lumiverses on one universe would overwrite each other
+	screens := map[int]int{} // index in state.Screens of each universe
+	patches := PatchScene(scene, opts.PatchOptions)
+	for iF, f := range scene.Fixtures() {
+		name := filepath.Base(f.Path())
+		for iP, p := range f.Transformed() {
+			patch := patches[iF][iP]
+			y := p.Y
+			if !opts.YDown {
+				y = float32(opts.Height) - y
+			}
+			iS, ok := screens[patch.Universe]
+			if !ok {
+				iS = len(state.Screens)
+				screens[patch.Universe] = iS
+				state.Screens = append(state.Screens, resolumeScreen{
+					Name:     fmt.Sprintf("%v U%v", name, patch.Universe),
+					Universe: patch.Universe,
+				})
+			}
+			state.Screens[iS].Fixtures = append(state.Screens[iS].Fixtures, resolumeFixture{
+				Name:       fmt.Sprintf("%v %v", name, iP),
+				Channel:    patch.Channel,
+				ColorSpace: pixelType(patch),
+				X:          formatFloat(p.X),
+				Y:          formatFloat(y),
+			})
+		}
+	}
+
+	io.WriteString(out, xml.Header)
+	enc := xml.NewEncoder(out)
+	enc.Indent("", "\t")
+	if err := enc.Encode(state); err != nil {
+		return err
+	}
+	_, err := io.WriteString(out, "\n")
+	return err
+}
@@ -0,0 +1,95 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+
+	"github.com/g3n/engine/math32"
+)
+
+// TouchDesignerOptions controls the table and lookup texture for TouchDesigner.
+type TouchDesignerOptions struct {
+	Width        float32 // Width of the frame u is measured against, 0 for the map bounds
+	Height       float32 // Height of the frame v is measured against, 0 for the map bounds
+	YDown        bool    // Map y runs downward like the video instead of up like scenebuild
+	TextureWidth int     // Texels per row of the lookup texture, 0 for a single row
+}
+
+// TouchDesignerUV returns the normalised texture coordinate of every point.
+// TouchDesigner puts the uv origin at the bottom left, like the y up of
+// scenebuild scenes, so v is only flipped for maps with YDown set.
+func TouchDesignerUV(pts []*math32.Vector3, opts TouchDesignerOptions) []*math32.Vector2 {
+	min, max := Bounds(pts)
+	origin := math32.NewVector2(min.X, min.Y)
+	size := math32.NewVector2(max.X-min.X, max.Y-min.Y)
+	if opts.Width > 0 && opts.Height > 0 {
+		origin.Set(0, 0)
+		size.Set(opts.Width, opts.Height)
+	}
+	if size.X == 0 {
+		size.X = 1
+	}
+	if size.Y == 0 {
+		size.Y = 1
+	}
+
+	uv := make([]*math32.Vector2, len(pts))
+	for iP, p := range pts {
+		u := (p.X - origin.X) / size.X
+		v := (p.Y - origin.Y) / size.Y
+		if opts.YDown {
+			v = 1 - v
+		}
+		uv[iP] = math32.NewVector2(u, v)
+	}
+	return uv
+}
+
+// WriteTouchDesignerTable writes a tab separated table with a header row and
+// one row per pixel in address order, ready for a Table DAT or DAT to CHOP.
+func WriteTouchDesignerTable(out io.Writer, pts []*math32.Vector3, opts TouchDesignerOptions) error {
+	w := bufio.NewWriter(out)
+	fmt.Fprintf(w, "index\ttx\tty\tu\tv\n")
+	for iP, uv := range TouchDesignerUV(pts, opts) {
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\n", iP,
+			formatFloat(pts[iP].X), formatFloat(pts[iP].Y),
+			formatFloat(uv.X), formatFloat(uv.Y))
+	}
+	return w.Flush()
+}
+
+// TouchDesignerTexture returns a 16 bit lookup texture where the texel for
+// pixel index i holds u in red and v in green. Texels fill rows of
+// TextureWidth from the top left; unused texels are transparent.
+func TouchDesignerTexture(pts []*math32.Vector3, opts TouchDesignerOptions) *image.NRGBA64 {
+	width := opts.TextureWidth
+	if width <= 0 || width > len(pts) {
+		width = len(pts)
+	}
+	if width == 0 {
+		width = 1
+	}
+	height := (len(pts) + width - 1) / width
+	if height == 0 {
+		height = 1
+	}
+
+	img := image.NewNRGBA64(image.Rect(0, 0, width, height))
+	for iP, uv := range TouchDesignerUV(pts, opts) {
+		img.SetNRGBA64(iP%width, iP/width, color.NRGBA64{
+			R: unit16(uv.X),
+			G: unit16(uv.Y),
+			B: 0,
+			A: 0xffff,
+		})
+	}
+	return img
+}
+
+// unit16 converts 0..1 into the full 16 bit range
+func unit16(v float32) uint16 {
+	return uint16(math32.Round(math32.Clamp(v, 0, 1) * 0xffff))
+}
@@ -0,0 +1,62 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/g3n/engine/math32"
+)
+
+func TestTouchDesignerUV(t *testing.T) {
+	// corners of a 200 x 100 frame, at the bottom left and top right of a
+	// scenebuild scene or the top left and bottom right of a resize map
+	pts := []*math32.Vector3{math32.NewVector3(0, 0, 0), math32.NewVector3(200, 100, 0)}
+	for _, c := range []struct {
+		opts     TouchDesignerOptions
+		expected [2]math32.Vector2
+	}{
+		// v counts up from the bottom of the frame, like scenebuild y
+		{TouchDesignerOptions{}, [2]math32.Vector2{{X: 0, Y: 0}, {X: 1, Y: 1}}},
+		{TouchDesignerOptions{YDown: true}, [2]math32.Vector2{{X: 0, Y: 1}, {X: 1, Y: 0}}},
+		// against a larger frame the points no longer reach the edges
+		{TouchDesignerOptions{Width: 400, Height: 400}, [2]math32.Vector2{{X: 0, Y: 0}, {X: 0.5, Y: 0.25}}},
+		{TouchDesignerOptions{Width: 400, Height: 400, YDown: true}, [2]math32.Vector2{{X: 0, Y: 1}, {X: 0.5, Y: 0.75}}},
+	} {
+		uv := TouchDesignerUV(pts, c.opts)
+		if *uv[0] != c.expected[0] || *uv[1] != c.expected[1] {
+			t.Errorf("%+v gave %v and %v, expected %v", c.opts, *uv[0], *uv[1], c.expected)
+		}
+	}
+}
+
+func TestWriteTouchDesignerTable(t *testing.T) {
+	pts := []*math32.Vector3{math32.NewVector3(0, 0, 0), math32.NewVector3(20, 10, 0), math32.NewVector3(5, 8, 0)}
+	var buf bytes.Buffer
+	if err := WriteTouchDesignerTable(&buf, pts, TouchDesignerOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	expected := "index\ttx\tty\tu\tv\n" +
+		"0\t0\t0\t0\t0\n" +
+		"1\t20\t10\t1\t1\n" +
+		"2\t5\t8\t0.25\t0.8\n"
+	if buf.String() != expected {
+		t.Errorf("Wrote %q, expected %q", buf.String(), expected)
+	}
+}
+
+func TestTouchDesignerTexture(t *testing.T) {
+	pts := []*math32.Vector3{math32.NewVector3(0, 0, 0), math32.NewVector3(20, 10, 0), math32.NewVector3(5, 8, 0)}
+	img := TouchDesignerTexture(pts, TouchDesignerOptions{TextureWidth: 2})
+	if b := img.Bounds(); b.Dx() != 2 || b.Dy() != 2 {
+		t.Fatalf("Texture is %v x %v, expected 2 x 2", b.Dx(), b.Dy())
+	}
+	if c := img.NRGBA64At(1, 0); c.R != 0xffff || c.G != 0xffff || c.A != 0xffff {
+		t.Errorf("Second texel %v, expected u 1 and v 1", c)
+	}
+	if c := img.NRGBA64At(0, 1); c.R != 0x4000 {
+		t.Errorf("Third texel u %v, expected a quarter", c.R)
+	}
+	if c := img.NRGBA64At(1, 1); c.A != 0 {
+		t.Errorf("Unused texel %v, expected transparent", c)
+	}
+}
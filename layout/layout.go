@@ -0,0 +1,297 @@
+// Package layout describes how LEDs are wired to the controller: how many
+// are on each pin, the order and depth of their colour channels and which
+// end of the strip is fed. Frames are built in address order as RGB and
+// encoded for the wiring just before they are sent.
+package layout
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Order is the order a strip takes its colour channels, like GRB. A W
+// channel takes the white shared by red, green and blue.
+type Order string
+
+// ParseOrder checks an order is made of R, G, B and at most one W
+func ParseOrder(s string) (Order, error) {
+	o := Order(strings.ToUpper(s))
+	if len(o) != 3 && len(o) != 4 {
+		return "", fmt.Errorf("colour order %v must be 3 or 4 channels, like GRB or RGBW", s)
+	}
+	for _, c := range "RGB" {
+		if strings.Count(string(o), string(c)) != 1 {
+			return "", fmt.Errorf("colour order %v needs one each of R, G and B", s)
+		}
+	}
+	if len(o) == 4 && !strings.Contains(string(o), "W") {
+		return "", fmt.Errorf("the fourth channel of %v must be W", s)
+	}
+	return o, nil
+}
+
+// Channels is the bytes each LED of the strip takes
+func (o Order) Channels() int {
+	return len(o)
+}
+
+// channels returns one LED's colour in the strip's order. A W channel takes
+// the white shared by red, green and blue, which is taken out of them.
+func (o Order) channels(c []uint16, r, g, b uint16) {
+	w := uint16(0)
+	if len(o) == 4 {
+		w = r
+		if g < w {
+			w = g
+		}
+		if b < w {
+			w = b
+		}
+		r, g, b = r-w, g-w, b-w
+	}
+	for iC := 0; iC < len(o); iC++ {
+		switch o[iC] {
+		case 'R':
+			c[iC] = r
+		case 'G':
+			c[iC] = g
+		case 'B':
+			c[iC] = b
+		case 'W':
+			c[iC] = w
+		}
+	}
+}
+
+// Strip is the LEDs on one pin
+type Strip struct {
+	Length  int
+	Order   Order
+	Reverse bool // Address 0 is at the far end of the strip
+	Wide    bool // 16 bits per channel, sent high byte first
+}
+
+// Bytes is the size of one LED of the strip
+func (s Strip) Bytes() int {
+	if s.Wide {
+		return s.Order.Channels() * 2
+	}
+	return s.Order.Channels()
+}
+
+// Layout is every pin of the controller in order. LED addresses run through
+// the pins in turn, the first LED of pin 2 follows the last of pin 1.
+type Layout struct {
+	Strips []Strip
+}
+
+// Uniform is the layout cameramap has always assumed, leds RGB LEDs on each
+// of pins pins
+func Uniform(pins, leds int) *Layout {
+	l := &Layout{}
+	for iP := 0; iP < pins; iP++ {
+		l.Strips = append(l.Strips, Strip{Length: leds, Order: "RGB"})
+	}
+	return l
+}
+
+// Parse reads a layout like "150:GRB,150,720:RGB:r,8x460,60:RGBW:16" with
+// one entry per pin: the LED count, then optionally the colour order, r for
+// a reversed strip and 16 for 16 bit channels. NxLENGTH repeats an entry for
+// N pins. Pins default to 8 bit RGB.
+func Parse(spec string) (*Layout, error) {
+	l := &Layout{}
+	for _, entry := range strings.Split(spec, ",") {
+		fields := strings.Split(strings.TrimSpace(entry), ":")
+		repeat := 1
+		count := fields[0]
+		if i := strings.Index(count, "x"); i >= 0 {
+			n, err := strconv.Atoi(count[:i])
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("bad repeat in %v", entry)
+			}
+			repeat, count = n, count[i+1:]
+		}
+		length, err := strconv.Atoi(count)
+		if err != nil || length < 0 {
+			return nil, fmt.Errorf("bad LED count in %v", entry)
+		}
+		s := Strip{Length: length, Order: "RGB"}
+		for _, f := range fields[1:] {
+			switch strings.ToLower(f) {
+			case "r":
+				s.Reverse = true
+				continue
+			case "16":
+				s.Wide = true
+				continue
+			case "8":
+				continue
+			}
+			if s.Order, err = ParseOrder(f); err != nil {
+				return nil, err
+			}
+		}
+		for iR := 0; iR < repeat; iR++ {
+			l.Strips = append(l.Strips, s)
+		}
+	}
+	return l, nil
+}
+
+// String is the layout in the form Parse reads
+func (l *Layout) String() string {
+	entries := []string{}
+	for _, s := range l.Strips {
+		e := fmt.Sprintf("%v:%v", s.Length, s.Order)
+		if s.Reverse {
+			e += ":r"
+		}
+		if s.Wide {
+			e += ":16"
+		}
+		entries = append(entries, e)
+	}
+	return strings.Join(entries, ",")
+}
+
+// Total is the number of LEDs on every pin
+func (l *Layout) Total() int {
+	total := 0
+	for _, s := range l.Strips {
+		total += s.Length
+	}
+	return total
+}
+
+// Size is the bytes of an encoded frame
+func (l *Layout) Size() int {
+	size := 0
+	for _, s := range l.Strips {
+		size += s.Length * s.Bytes()
+	}
+	return size
+}
+
+// DMXChannels is the size of a DMX universe
+const DMXChannels = 512
+
+// Universes returns the byte offset of each DMX universe in an encoded
+// frame. Each universe holds as many whole LEDs as fit, so an LED is never
+// split between two: 170 RGB LEDs, 128 RGBW or 85 16 bit RGB.
+func (l *Layout) Universes() []int {
+	starts := []int{0}
+	used, at := 0, 0
+	for _, s := range l.Strips {
+		n := s.Bytes()
+		for iL := 0; iL < s.Length; iL++ {
+			if used+n > DMXChannels {
+				starts = append(starts, at)
+				used = 0
+			}
+			used += n
+			at += n
+		}
+	}
+	return starts
+}
+
+// Address returns the address of LED index on pin, both from 0
+func (l *Layout) Address(pin, index int) int {
+	addr := index
+	for iP := 0; iP < pin && iP < len(l.Strips); iP++ {
+		addr += l.Strips[iP].Length
+	}
+	return addr
+}
+
+// Locate returns the pin and index on the pin of an address, both from 0.
+// Pin is -1 for addresses past the last LED.
+func (l *Layout) Locate(addr int) (pin, index int) {
+	for iP, s := range l.Strips {
+		if addr < s.Length {
+			return iP, addr
+		}
+		addr -= s.Length
+	}
+	return -1, addr
+}
+
+// Encode converts frame, 8 bit RGB in address order, to the bytes the
+// controller expects. LEDs missing from the end of frame are sent off. dst
+// is reused when it is large enough.
+func (l *Layout) Encode(frame, dst []byte) []byte {
+	wide := make([]uint16, len(frame))
+	for iX, v := range frame {
+		wide[iX] = uint16(v) * 257
+	}
+	return (&Encoder{layout: l}).Encode16(wide, dst)
+}
+
+// Encoder converts 16 bit frames for the wiring. With dithering, 8 bit
+// strips carry the rounding error of each channel into the next frame, so
+// their average over a few frames has the full precision.
+type Encoder struct {
+	layout *Layout
+	dither bool
+	errs   []uint32 // Rounding error carried over, per 8 bit channel
+}
+
+// NewEncoder encodes frames for l
+func NewEncoder(l *Layout, dither bool) *Encoder {
+	return &Encoder{layout: l, dither: dither}
+}
+
+// Encode16 converts frame, 16 bit RGB in address order, like Encode
+func (e *Encoder) Encode16(frame []uint16, dst []byte) []byte {
+	l := e.layout
+	size := l.Size()
+	if cap(dst) < size {
+		dst = make([]byte, size)
+	}
+	dst = dst[:size]
+	if e.dither && len(e.errs) < size {
+		e.errs = make([]uint32, size)
+	}
+	var c [4]uint16
+	addr, at := 0, 0
+	for _, s := range l.Strips {
+		ch := s.Order.Channels()
+		for iL := 0; iL < s.Length; iL++ {
+			src := addr + iL
+			if s.Reverse {
+				src = addr + s.Length - 1 - iL
+			}
+			var r, g, b uint16
+			if src*3+2 < len(frame) {
+				r, g, b = frame[src*3], frame[src*3+1], frame[src*3+2]
+			}
+			s.Order.channels(c[:ch], r, g, b)
+			for _, v := range c[:ch] {
+				if s.Wide {
+					dst[at], dst[at+1] = byte(v>>8), byte(v)
+					at += 2
+					continue
+				}
+				dst[at] = e.narrow(at, v)
+				at++
+			}
+		}
+		addr += s.Length
+	}
+	return dst
+}
+
+// narrow rounds a 16 bit channel to 8 bits, dithered by the error left
+// over at the same byte of the last frame
+func (e *Encoder) narrow(at int, v uint16) byte {
+	x := uint32(v) * 255
+	if !e.dither {
+		return byte((x + 65535/2) / 65535)
+	}
+	x += e.errs[at]
+	out := x / 65535
+	e.errs[at] = x - out*65535
+	return byte(out)
+}
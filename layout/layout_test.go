@@ -0,0 +1,95 @@
+package layout
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	l, err := Parse("2x150:grb,720:RGB:r,0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := l.String(); got != "150:GRB,150:GRB,720:RGB:r,0:RGB" {
+		t.Errorf("Parsed %v", got)
+	}
+	if l.Total() != 1020 {
+		t.Errorf("Total %v, expected 1020", l.Total())
+	}
+	if pin, index := l.Locate(l.Address(2, 5)); pin != 2 || index != 5 {
+		t.Errorf("Located pin %v index %v, expected pin 2 index 5", pin, index)
+	}
+	for _, bad := range []string{"abc", "10:RGX", "10:RGBB", "0x10"} {
+		if _, err := Parse(bad); err == nil {
+			t.Errorf("Parsed %v", bad)
+		}
+	}
+}
+
+func TestEncode(t *testing.T) {
+	l, _ := Parse("2:GRB,2:RGB:r,1:RGBW")
+	frame := []byte{
+		1, 2, 3, 4, 5, 6, // pin 1
+		7, 8, 9, 10, 11, 12, // pin 2, fed from the far end
+		20, 30, 40, // pin 3, white shared by all three
+	}
+	want := []byte{
+		2, 1, 3, 5, 4, 6,
+		10, 11, 12, 7, 8, 9,
+		0, 10, 20, 20,
+	}
+	if got := l.Encode(frame, nil); !bytes.Equal(got, want) {
+		t.Errorf("Encoded %v, expected %v", got, want)
+	}
+	// a short frame leaves the rest off
+	if got := l.Encode(frame[:3], nil); got[3] != 0 || got[2] != 3 {
+		t.Errorf("Encoded short frame %v", got)
+	}
+}
+
+func TestEncode16(t *testing.T) {
+	l, _ := Parse("1:RGBW:16,1")
+	frame := []uint16{0x1234, 0xffff, 0x1000, 0, 0, 0}
+	want := []byte{0x02, 0x34, 0xef, 0xff, 0, 0, 0x10, 0x00, 0, 0, 0}
+	if got := NewEncoder(l, false).Encode16(frame, nil); !bytes.Equal(got, want) {
+		t.Errorf("Encoded %x, expected %x", got, want)
+	}
+}
+
+func TestDither(t *testing.T) {
+	l := Uniform(1, 1)
+	e := NewEncoder(l, true)
+	// a quarter of the way from 10 to 11
+	v := uint16((10*255 + 64) * 257 / 255)
+	sum := 0
+	for iF := 0; iF < 100; iF++ {
+		out := e.Encode16([]uint16{v, 0, 0}, nil)
+		if out[0] != 10 && out[0] != 11 {
+			t.Fatalf("Frame %v is %v, expected 10 or 11", iF, out[0])
+		}
+		sum += int(out[0])
+	}
+	if sum < 1020 || sum > 1030 {
+		t.Errorf("Average over 100 frames is %v, expected about 10.25", float64(sum)/100)
+	}
+	// without dithering the same frame always rounds down
+	if out := NewEncoder(l, false).Encode16([]uint16{v, 0, 0}, nil); out[0] != 10 {
+		t.Errorf("Rounded to %v, expected 10", out[0])
+	}
+}
+
+func TestUniverses(t *testing.T) {
+	l, _ := Parse("200,130:RGBW,90:RGB:16")
+	// 170 RGB, then 30 RGB and 105 RGBW, then 25 RGBW and 68 wide, then the
+	// last 22 wide
+	want := []int{0, 510, 1020, 1528}
+	got := l.Universes()
+	if len(got) != len(want) {
+		t.Fatalf("Universes start at %v, expected %v", got, want)
+	}
+	for iU := range want {
+		if got[iU] != want[iU] {
+			t.Fatalf("Universes start at %v, expected %v", got, want)
+		}
+	}
+}
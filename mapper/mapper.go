@@ -0,0 +1,125 @@
+// Package mapper finds where each LED is in a camera view by lighting them
+// one at a time, the same sequence cmd/cameramap runs.
+package mapper
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"time"
+
+	"github.com/tgreiser/cymapper/detect"
+	"github.com/tgreiser/cymapper/layout"
+	"github.com/tgreiser/cymapper/output"
+	"gocv.io/x/gocv"
+)
+
+// color for the rect when light detected
+var blue = color.RGBA{0, 0, 255, 0}
+
+// Config describes the LEDs to map
+type Config struct {
+	Leds       int            // LEDs per pin
+	Pins       int            // Pins with LEDs connected
+	Brightness int            // LED brightness (1-255)
+	StartPin   int            // Skip to a certain pin, from 1
+	Delay      time.Duration  // Time each LED is lit before it is detected
+	Radius     int            // Radius of the gaussian blur used for noise reduction
+	Layout     *layout.Layout // Wiring of the LEDs, nil for Pins strips of Leds RGB LEDs
+}
+
+// Progress is reported after each LED is detected
+type Progress struct {
+	Done   int           // LEDs detected so far
+	Total  int           // LEDs to detect
+	Point  image.Point   // Where the last LED was seen by the first camera
+	Points []image.Point // Where the last LED was seen by each camera
+	Frame  *image.RGBA   // First camera's frame with the points found so far marked
+}
+
+// Frame returns the bytes for total LEDs with only lit on, at brightness
+func Frame(total, lit, brightness int) []byte {
+	buf := make([]byte, total*3)
+	if lit >= 0 && lit < total {
+		for iC := 0; iC < 3; iC++ {
+			buf[lit*3+iC] = byte(brightness)
+		}
+	}
+	return buf
+}
+
+// Run lights each LED in turn and finds it in the webcam view, returning the
+// points in address order. Progress is called from the goroutine running
+// Run. Closing stop ends the run early with the points found so far.
+func Run(cfg Config, out output.Output, webcam *gocv.VideoCapture, stop <-chan struct{}, progress func(Progress)) ([]image.Point, error) {
+	maps, err := RunCameras(cfg, out, []*gocv.VideoCapture{webcam}, stop, progress)
+	return maps[0], err
+}
+
+// RunCameras is Run with several webcams watching the same LEDs, for
+// cmd/triangulate. It returns a map for each webcam, and progress shows the
+// first.
+func RunCameras(cfg Config, out output.Output, webcams []*gocv.VideoCapture, stop <-chan struct{}, progress func(Progress)) ([][]image.Point, error) {
+	maps := make([][]image.Point, len(webcams))
+	lay := cfg.Layout
+	if lay == nil {
+		lay = layout.Uniform(cfg.Pins, cfg.Leds)
+	}
+	out = output.WithLayout(out, lay, false)
+	total := lay.Total()
+	start := lay.Address(cfg.StartPin-1, 0)
+	if cfg.StartPin < 1 || start >= total {
+		return maps, errors.New("start pin is outside the LEDs being mapped")
+	}
+
+	imgs := make([]gocv.Mat, len(webcams))
+	detectors := make([]*detect.Detector, len(webcams))
+	for iC := range webcams {
+		imgs[iC] = gocv.NewMat()
+		defer imgs[iC].Close()
+		detectors[iC] = detect.NewDetector(cfg.Radius)
+		defer detectors[iC].Close()
+	}
+	// turn everything off when done
+	defer out.Write(Frame(total, -1, 0))
+
+	for iL := start; iL < total; iL++ {
+		select {
+		case <-stop:
+			return maps, nil
+		default:
+		}
+		if err := out.Write(Frame(total, iL, cfg.Brightness)); err != nil {
+			return maps, err
+		}
+		// keep reading while the LED settles so every camera buffer is current
+		settle := time.Now().Add(cfg.Delay)
+		for ok := false; !ok || time.Now().Before(settle); {
+			ok = true
+			for iC, webcam := range webcams {
+				if !webcam.Read(&imgs[iC]) {
+					return maps, errors.New("cannot read camera")
+				}
+				ok = ok && !imgs[iC].Empty()
+			}
+		}
+
+		pts := make([]image.Point, len(webcams))
+		for iC := range webcams {
+			pts[iC] = detectors[iC].Brightest(imgs[iC]).Point
+			maps[iC] = append(maps[iC], pts[iC])
+		}
+		if progress == nil {
+			continue
+		}
+		for _, p := range maps[0] {
+			detect.Mark(&imgs[0], p, blue)
+		}
+		p := Progress{Done: len(maps[0]), Total: total - start, Point: pts[0], Points: pts}
+		if frame, err := imgs[0].ToImage(); err == nil {
+			p.Frame, _ = frame.(*image.RGBA)
+		}
+		progress(p)
+	}
+	return maps, nil
+}
@@ -0,0 +1,91 @@
+package output
+
+import (
+	"encoding/binary"
+	"net"
+	"strconv"
+
+	"github.com/tgreiser/cymapper/layout"
+)
+
+// ArtNetPort is the UDP port Art-Net nodes listen on
+const ArtNetPort = 6454
+
+// UniverseChannels is the DMX channels used per universe when no layout is
+// set, 170 RGB pixels
+const UniverseChannels = 510
+
+// ArtNet sends frames to an Art-Net node, splitting them across universes
+// without splitting a pixel
+type ArtNet struct {
+	conn     net.PacketConn
+	addr     net.Addr
+	universe int   // Universe of the first pixel
+	starts   []int // Byte offset of each universe, nil for RGB pixels
+	seq      byte
+}
+
+// OpenArtNet sends to host, or host:port, starting at universe
+func OpenArtNet(host string, universe int) (*ArtNet, error) {
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		host = net.JoinHostPort(host, strconv.Itoa(ArtNetPort))
+	}
+	addr, err := net.ResolveUDPAddr("udp", host)
+	if err != nil {
+		return nil, err
+	}
+	// unconnected, so a node that restarts does not end the stream
+	conn, err := net.ListenPacket("udp", ":0")
+	if err != nil {
+		return nil, err
+	}
+	return &ArtNet{conn: conn, addr: addr, universe: universe}, nil
+}
+
+// SetLayout packs the pixels of frames encoded for l into universes, as
+// many whole pixels as fit in each
+func (a *ArtNet) SetLayout(l *layout.Layout) {
+	a.starts = l.Universes()
+}
+
+func (a *ArtNet) Write(frame []byte) error {
+	// sequence 0 turns ordering off, so count 1-255
+	a.seq = a.seq%255 + 1
+	for iU, off := 0, 0; off < len(frame); iU++ {
+		end := off + UniverseChannels
+		switch {
+		case iU+1 < len(a.starts):
+			end = a.starts[iU+1]
+		case a.starts != nil:
+			end = off + layout.DMXChannels
+		}
+		if end > len(frame) {
+			end = len(frame)
+		}
+		if _, err := a.conn.WriteTo(artDmx(a.universe+iU, a.seq, frame[off:end]), a.addr); err != nil {
+			return err
+		}
+		off = end
+	}
+	return nil
+}
+
+func (a *ArtNet) Close() error {
+	return a.conn.Close()
+}
+
+// artDmx builds an ArtDmx packet carrying data to a 15 bit port address
+func artDmx(universe int, seq byte, data []byte) []byte {
+	// the length must be even
+	n := len(data) + len(data)%2
+	pkt := make([]byte, 18+n)
+	copy(pkt, "Art-Net\x00")
+	binary.LittleEndian.PutUint16(pkt[8:], 0x5000) // OpDmx
+	binary.BigEndian.PutUint16(pkt[10:], 14)       // protocol version
+	pkt[12] = seq
+	pkt[14] = byte(universe & 0xff)        // sub net and universe
+	pkt[15] = byte((universe >> 8) & 0x7f) // net
+	binary.BigEndian.PutUint16(pkt[16:], uint16(n))
+	copy(pkt[18:], data)
+	return pkt
+}
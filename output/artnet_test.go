@@ -0,0 +1,40 @@
+package output
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/tgreiser/cymapper/layout"
+)
+
+func TestArtNetUniverses(t *testing.T) {
+	node, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer node.Close()
+	a, err := OpenArtNet(node.LocalAddr().String(), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+
+	// 130 RGBW pixels are 128 in the first universe and 2 in the next
+	l, _ := layout.Parse("130:RGBW")
+	a.SetLayout(l)
+	if err := a.Write(make([]byte, l.Size())); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 1024)
+	for _, want := range []struct{ universe, size int }{{3, 512}, {4, 8}} {
+		node.SetReadDeadline(time.Now().Add(time.Second))
+		n, _, err := node.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if u, size := int(buf[14]), n-18; u != want.universe || size != want.size {
+			t.Errorf("Universe %v of %v bytes, expected %v of %v", u, size, want.universe, want.size)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package output
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/tgreiser/cymapper/correct"
+	"github.com/tgreiser/cymapper/layout"
+)
+
+// Flags are the command line flags shared by the commands that drive LEDs:
+// where frames are sent, how the LEDs are wired and how their colours and
+// current are corrected
+type Flags struct {
+	Spec             *string
+	Layout           *string
+	White            *string
+	MaxAmps          *float64
+	ChannelMilliamps *float64
+	IdleMilliamps    *float64
+	Dither           *bool
+}
+
+// AddFlags defines the output flags on fs
+func AddFlags(fs *flag.FlagSet) *Flags {
+	return &Flags{
+		Spec:             fs.String("output", "COM8", "LED output: serial:<port> or artnet:<host>[:port][/universe]"),
+		Layout:           fs.String("layout", "", "LEDs on each pin as count[:order][:r][:16], comma separated, e.g. 150:GRB,2x150,720:RGB:r,60:RGBW:16"),
+		White:            fs.String("white", "", "White point as a colour temperature like 5000, 6600 is neutral, or r:g:b scales. Comma separated for one per map file"),
+		MaxAmps:          fs.Float64("max-amps", 0, "Most current the LEDs may draw, frames are dimmed to stay under it, 0 for no limit"),
+		ChannelMilliamps: fs.Float64("led-ma", 20, "Current of one colour channel of an LED at full brightness, in milliamps"),
+		IdleMilliamps:    fs.Float64("idle-ma", 1, "Current of an LED when it is off, in milliamps"),
+		Dither:           fs.Bool("dither", true, "Dither colours over time on 8 bit strips, so dim colours fade smoothly"),
+	}
+}
+
+// Wiring returns the -layout, or fallback when none was given
+func (f *Flags) Wiring(fallback *layout.Layout) (*layout.Layout, error) {
+	if *f.Layout == "" {
+		return fallback, nil
+	}
+	return layout.Parse(*f.Layout)
+}
+
+// Open opens -output for LEDs wired as lay. Frames are corrected by pipe,
+// with the -white of each fixture of counts LEDs and the -max-amps limit.
+func (f *Flags) Open(lay *layout.Layout, pipe *correct.Pipeline, counts []int) (*Wired, error) {
+	if *f.White != "" {
+		if err := pipe.SetWhites(*f.White, counts); err != nil {
+			return nil, fmt.Errorf("bad white point: %v", err)
+		}
+	}
+	if *f.MaxAmps > 0 {
+		pipe.Limiter = &correct.Limiter{Amps: *f.MaxAmps, ChannelMilliamps: *f.ChannelMilliamps, IdleMilliamps: *f.IdleMilliamps}
+	}
+	out, err := Open(*f.Spec)
+	if err != nil {
+		return nil, fmt.Errorf("when connecting to %v: %v", *f.Spec, err)
+	}
+	// the layout sends every LED it describes
+	wired := WithLayout(out, lay, *f.Dither)
+	wired.Correct = pipe
+	return wired, nil
+}
@@ -0,0 +1,43 @@
+// Package output sends frames of LED colors to a controller.
+package output
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Output receives frames of RGB bytes, 3 per LED in address order
+type Output interface {
+	Write(frame []byte) error
+	Close() error
+}
+
+// Open opens an output described by spec:
+//
+//	serial:COM8 or serial:/dev/ttyACM0   a Teensy on a serial port
+//	artnet:192.168.1.50[:port][/universe] an Art-Net node
+//
+// A spec with no type is a serial port.
+func Open(spec string) (Output, error) {
+	kind, target := "serial", spec
+	if i := strings.Index(spec, ":"); i > 1 {
+		// COM ports and paths have no type, "c:" is a drive letter
+		kind, target = spec[:i], spec[i+1:]
+	}
+	switch kind {
+	case "serial":
+		return OpenSerial(target, DefaultBaud)
+	case "artnet":
+		universe := 0
+		if i := strings.LastIndex(target, "/"); i >= 0 {
+			u, err := strconv.Atoi(target[i+1:])
+			if err != nil {
+				return nil, fmt.Errorf("bad universe in %v: %v", spec, err)
+			}
+			target, universe = target[:i], u
+		}
+		return OpenArtNet(target, universe)
+	}
+	return nil, fmt.Errorf("unknown output %v, use serial:<port> or artnet:<host>", spec)
+}
@@ -0,0 +1,31 @@
+package output
+
+import (
+	"github.com/tarm/serial"
+)
+
+// DefaultBaud is the rate cameramap uses to talk to the Teensy
+const DefaultBaud = 256000
+
+// Serial sends frames to a Teensy over USB serial, one write per frame
+type Serial struct {
+	port *serial.Port
+}
+
+// OpenSerial opens a COM port (COM8) or device (/dev/ttyACM0)
+func OpenSerial(name string, baud int) (*Serial, error) {
+	port, err := serial.OpenPort(&serial.Config{Name: name, Baud: baud})
+	if err != nil {
+		return nil, err
+	}
+	return &Serial{port: port}, nil
+}
+
+func (s *Serial) Write(frame []byte) error {
+	_, err := s.port.Write(frame)
+	return err
+}
+
+func (s *Serial) Close() error {
+	return s.port.Close()
+}
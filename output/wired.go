@@ -0,0 +1,48 @@
+package output
+
+import (
+	"github.com/tgreiser/cymapper/correct"
+	"github.com/tgreiser/cymapper/layout"
+)
+
+// Wired encodes frames for the controller's wiring, colour order, channel
+// depth, strip lengths and direction, before passing them on
+type Wired struct {
+	Output
+	Correct *correct.Pipeline // Applied to every 8 bit frame, nil to send colours as they are
+	enc     *layout.Encoder
+	wide    []uint16
+	buf     []byte
+}
+
+// WithLayout sends frames to out encoded for l. Dither carries rounding
+// error between frames when 16 bit frames are sent to 8 bit strips. An
+// Art-Net output packs whole pixels of l into each universe.
+func WithLayout(out Output, l *layout.Layout, dither bool) *Wired {
+	if a, ok := out.(*ArtNet); ok {
+		a.SetLayout(l)
+	}
+	return &Wired{Output: out, enc: layout.NewEncoder(l, dither)}
+}
+
+// Write corrects and sends a frame of 8 bit RGB in address order
+func (w *Wired) Write(frame []byte) error {
+	if w.Correct != nil {
+		w.wide = w.Correct.Apply(frame, w.wide)
+		return w.Write16(w.wide)
+	}
+	if cap(w.wide) < len(frame) {
+		w.wide = make([]uint16, len(frame))
+	}
+	w.wide = w.wide[:len(frame)]
+	for iX, v := range frame {
+		w.wide[iX] = uint16(v) * 257
+	}
+	return w.Write16(w.wide)
+}
+
+// Write16 sends a frame of 16 bit RGB in address order, without correction
+func (w *Wired) Write16(frame []uint16) error {
+	w.buf = w.enc.Encode16(frame, w.buf)
+	return w.Output.Write(w.buf)
+}
@@ -0,0 +1,157 @@
+// Package power estimates the current a scene of LEDs draws, to check power
+// supplies and injection points before the rig is switched on.
+package power
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/tgreiser/cymapper/layout"
+)
+
+// Config describes the LEDs and the limits to check them against. A limit
+// of 0 is not checked.
+type Config struct {
+	Volts            float64 // Supply voltage, for watts
+	ChannelMilliamps float64 // Current of one colour channel at full, about 20 for WS2812
+	IdleMilliamps    float64 // Current of each LED when it is off, about 1
+	Brightness       float64 // Brightness the LEDs are driven at, 0-1
+	Typical          float64 // Average level of typical content, 0-1 of full white
+	PinAmps          float64 // Most current one pin's power injection should carry
+	FixtureAmps      float64 // Most current one fixture should draw
+	SupplyAmps       float64 // Most current the supplies deliver together
+}
+
+// Load is the current drawn by a group of LEDs
+type Load struct {
+	Name    string
+	Pixels  int
+	Worst   float64 // Amps with every channel full
+	Typical float64 // Amps for typical content
+	Limit   float64 // Amps allowed, 0 for no limit
+}
+
+// Over reports whether the worst case is more than the limit
+func (l Load) Over() bool {
+	return l.Limit > 0 && l.Worst > l.Limit
+}
+
+func (l Load) String() string {
+	s := fmt.Sprintf("%v: %v LEDs, worst %.2f A, typical %.2f A", l.Name, l.Pixels, l.Worst, l.Typical)
+	if l.Over() {
+		s += fmt.Sprintf(", OVER %.2f A", l.Limit)
+	}
+	return s
+}
+
+func (l *Load) add(o Load) {
+	l.Pixels += o.Pixels
+	l.Worst += o.Worst
+	l.Typical += o.Typical
+}
+
+// Fixture is a named run of LEDs, in address order
+type Fixture struct {
+	Name   string
+	Pixels int
+}
+
+// Report is the estimated load of each fixture, each pin and the scene
+type Report struct {
+	Config
+	Fixtures []Load
+	Pins     []Load
+	Total    Load
+	Unwired  int // LEDs past the last pin of the layout
+}
+
+// Estimate works out the current of fixtures wired as lay. Only pins with
+// LEDs in the scene are reported.
+func Estimate(fixtures []Fixture, lay *layout.Layout, cfg Config) Report {
+	r := Report{Config: cfg, Total: Load{Name: "Total", Limit: cfg.SupplyAmps}}
+	pins := make([]Load, len(lay.Strips))
+	for iP := range pins {
+		pins[iP] = Load{Name: fmt.Sprintf("Pin %v", iP+1), Limit: cfg.PinAmps}
+	}
+
+	addr := 0
+	for _, f := range fixtures {
+		load := Load{Name: f.Name, Limit: cfg.FixtureAmps}
+		for iL := 0; iL < f.Pixels; iL++ {
+			channels := 3
+			pin, _ := lay.Locate(addr)
+			if pin >= 0 {
+				channels = lay.Strips[pin].Order.Channels()
+			} else {
+				r.Unwired++
+			}
+			led := cfg.led(channels)
+			load.add(led)
+			if pin >= 0 {
+				pins[pin].add(led)
+			}
+			addr++
+		}
+		r.Fixtures = append(r.Fixtures, load)
+		r.Total.add(load)
+	}
+	for _, p := range pins {
+		if p.Pixels > 0 {
+			r.Pins = append(r.Pins, p)
+		}
+	}
+	return r
+}
+
+// led is the load of one LED with channels colour channels
+func (cfg Config) led(channels int) Load {
+	lit := float64(channels) * cfg.ChannelMilliamps * cfg.Brightness
+	return Load{
+		Pixels:  1,
+		Worst:   (lit + cfg.IdleMilliamps) / 1000,
+		Typical: (lit*cfg.Typical + cfg.IdleMilliamps) / 1000,
+	}
+}
+
+// Over lists every fixture, pin and the total that are over their limits
+func (r Report) Over() []Load {
+	over := []Load{}
+	for _, l := range append(append(append([]Load{}, r.Fixtures...), r.Pins...), r.Total) {
+		if l.Over() {
+			over = append(over, l)
+		}
+	}
+	return over
+}
+
+// Write prints the report as a table
+func (r Report) Write(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "\tLEDs\tWorst A\tWorst W\tTypical A\tTypical W\tLimit A\n")
+	rows := func(loads []Load) {
+		for _, l := range loads {
+			fmt.Fprintf(tw, "%v\t%v\t%.2f\t%.1f\t%.2f\t%.1f\t",
+				l.Name, l.Pixels, l.Worst, l.Worst*r.Volts, l.Typical, l.Typical*r.Volts)
+			switch {
+			case l.Over():
+				fmt.Fprintf(tw, "%.2f\tOVER\n", l.Limit)
+			case l.Limit > 0:
+				fmt.Fprintf(tw, "%.2f\n", l.Limit)
+			default:
+				fmt.Fprintf(tw, "-\n")
+			}
+		}
+	}
+	rows(r.Fixtures)
+	rows(r.Pins)
+	rows([]Load{r.Total})
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if r.Unwired > 0 {
+		_, err := fmt.Fprintf(w, "%v LEDs are past the last pin of the layout, counted as RGB\n", r.Unwired)
+		return err
+	}
+	return nil
+}
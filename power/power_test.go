@@ -0,0 +1,42 @@
+package power
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/tgreiser/cymapper/layout"
+)
+
+func TestEstimate(t *testing.T) {
+	lay, _ := layout.Parse("100,100:RGBW")
+	cfg := Config{Volts: 5, ChannelMilliamps: 20, IdleMilliamps: 1, Brightness: 0.5, Typical: 0.25, PinAmps: 4, SupplyAmps: 10}
+	r := Estimate([]Fixture{{"left", 150}, {"right", 100}}, lay, cfg)
+
+	near := func(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+	// 100 RGB LEDs at 31mA, then 50 RGBW at 41mA
+	if l := r.Fixtures[0]; l.Pixels != 150 || !near(l.Worst, 3.1+2.05) {
+		t.Errorf("Left is %v", l)
+	}
+	if l := r.Pins[1]; l.Pixels != 100 || !near(l.Worst, 4.1) || !l.Over() {
+		t.Errorf("Pin 2 is %v, expected 4.1 A over the limit", l)
+	}
+	if !near(r.Pins[0].Typical, 100*(7.5+1)/1000) {
+		t.Errorf("Pin 1 typical is %v", r.Pins[0].Typical)
+	}
+	if r.Unwired != 50 || r.Total.Pixels != 250 || r.Total.Over() {
+		t.Errorf("Total is %v with %v unwired", r.Total, r.Unwired)
+	}
+	if over := r.Over(); len(over) != 1 || over[0].Name != "Pin 2" {
+		t.Errorf("Over %v, expected pin 2", over)
+	}
+
+	var b bytes.Buffer
+	if err := r.Write(&b); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), "OVER") || !strings.Contains(b.String(), "50 LEDs are past") {
+		t.Errorf("Report is missing warnings:\n%v", b.String())
+	}
+}
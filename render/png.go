@@ -0,0 +1,92 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"strconv"
+
+	"github.com/g3n/engine/math32"
+	"github.com/tgreiser/cymapper/cmd/scenebuild/fixture"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+var (
+	black = color.RGBA{0, 0, 0, 255}
+	white = color.RGBA{255, 255, 255, 255}
+	gray  = color.RGBA{128, 128, 128, 255}
+	red   = color.RGBA{255, 0, 0, 255}
+)
+
+// Image draws the scene into a new RGBA image, ready for png.Encode.
+func Image(scene *fixture.Scene, opts Options) *image.RGBA {
+	l := newLayout(scene, opts)
+	img := image.NewRGBA(image.Rect(0, 0, l.width, l.height))
+	draw.Draw(img, img.Bounds(), &image.Uniform{black}, image.ZP, draw.Src)
+
+	if opts.Width > 0 && opts.Height > 0 {
+		x0, y0 := l.project(0, 0)
+		x1, y1 := l.project(opts.Width, opts.Height)
+		drawRect(img, x0, y0, x1, y1, white)
+	}
+
+	labels := &font.Drawer{Dst: img, Src: &image.Uniform{white}, Face: basicfont.Face7x13}
+	address := 0
+	for _, f := range scene.Fixtures() {
+		pts := l.fixturePoints(f, address)
+		address += len(pts)
+
+		if opts.Boxes {
+			x0, y0, x1, y1 := l.box(f)
+			drawRect(img, x0, y0, x1, y1, red)
+		}
+		for _, strip := range l.strips(pts) {
+			for iP := 1; iP < len(strip); iP++ {
+				drawLine(img, strip[iP-1].X, strip[iP-1].Y, strip[iP].X, strip[iP].Y, gray)
+			}
+		}
+		for _, p := range pts {
+			fillCircle(img, p.X, p.Y, opts.PointRadius, p.Color)
+		}
+		for _, p := range pts {
+			if opts.LabelEvery > 0 && p.Address%opts.LabelEvery == 0 {
+				labels.Dot = fixed.P(int(p.X+opts.PointRadius+1), int(p.Y-opts.PointRadius-1))
+				labels.DrawString(strconv.Itoa(p.Address))
+			}
+		}
+	}
+	return img
+}
+
+func drawRect(img *image.RGBA, x0, y0, x1, y1 float32, c color.RGBA) {
+	drawLine(img, x0, y0, x1, y0, c)
+	drawLine(img, x1, y0, x1, y1, c)
+	drawLine(img, x1, y1, x0, y1, c)
+	drawLine(img, x0, y1, x0, y0, c)
+}
+
+// drawLine steps one pixel at a time along the longest axis
+func drawLine(img *image.RGBA, x0, y0, x1, y1 float32, c color.RGBA) {
+	steps := int(math32.Ceil(math32.Max(math32.Abs(x1-x0), math32.Abs(y1-y0))))
+	if steps == 0 {
+		img.SetRGBA(int(x0), int(y0), c)
+		return
+	}
+	for iX := 0; iX <= steps; iX++ {
+		t := float32(iX) / float32(steps)
+		img.SetRGBA(int(math32.Round(x0+(x1-x0)*t)), int(math32.Round(y0+(y1-y0)*t)), c)
+	}
+}
+
+func fillCircle(img *image.RGBA, cx, cy, r float32, c color.RGBA) {
+	for y := int(math32.Floor(cy - r)); y <= int(math32.Ceil(cy+r)); y++ {
+		for x := int(math32.Floor(cx - r)); x <= int(math32.Ceil(cx+r)); x++ {
+			dx, dy := float32(x)-cx, float32(y)-cy
+			if dx*dx+dy*dy <= r*r {
+				img.SetRGBA(x, y, c)
+			}
+		}
+	}
+}
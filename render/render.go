@@ -0,0 +1,134 @@
+// Package render draws map and scene files to SVG and PNG without OpenGL,
+// using the same colours as the scenebuild GUI.
+package render
+
+import (
+	"image/color"
+
+	"github.com/g3n/engine/math32"
+	hsl "github.com/gerow/go-color"
+	"github.com/tgreiser/cymapper/cmd/scenebuild/fixture"
+)
+
+// Options controls what is drawn and how large the output is.
+type Options struct {
+	Width       float32 // Scene frame width, 0 to skip the frame
+	Height      float32 // Scene frame height, 0 to skip the frame
+	Scale       float32 // Output pixels per scene unit
+	Margin      float32 // Output pixels around the drawing
+	PointRadius float32 // Radius of each LED in output pixels
+	LabelEvery  int     // Label every Nth address, 0 for no labels
+	StripLength int     // LEDs per strip, polylines break between strips; 0 for one line per fixture
+	YDown       bool    // Draw y downward like a camera frame; by default y points up as in scenebuild
+	Boxes       bool    // Draw fixture bounding boxes
+}
+
+// DefaultOptions returns the options used by cmd/preview.
+func DefaultOptions() Options {
+	return Options{
+		Width:       1280,
+		Height:      720,
+		Scale:       1,
+		Margin:      20,
+		PointRadius: 3,
+		LabelEvery:  50,
+		Boxes:       true,
+	}
+}
+
+// point is an LED ready to draw, in output pixels.
+type point struct {
+	X, Y    float32
+	Address int
+	Color   color.RGBA
+}
+
+// layout converts scene coordinates to output pixels.
+type layout struct {
+	opts   Options
+	min    *math32.Vector3
+	max    *math32.Vector3
+	width  int
+	height int
+}
+
+func newLayout(scene *fixture.Scene, opts Options) *layout {
+	if opts.Scale <= 0 {
+		opts.Scale = 1
+	}
+	l := &layout{opts: opts}
+	l.min = math32.NewVector3(0, 0, 0)
+	l.max = math32.NewVector3(opts.Width, opts.Height, 0)
+	pts := scene.Points()
+	if opts.Width <= 0 || opts.Height <= 0 {
+		if len(pts) > 0 {
+			l.min = pts[0].Clone()
+			l.max = pts[0].Clone()
+		}
+	}
+	for _, p := range pts {
+		l.min.Min(p)
+		l.max.Max(p)
+	}
+	l.width = int(math32.Ceil((l.max.X-l.min.X)*opts.Scale + 2*opts.Margin))
+	l.height = int(math32.Ceil((l.max.Y-l.min.Y)*opts.Scale + 2*opts.Margin))
+	if l.width < 1 {
+		l.width = 1
+	}
+	if l.height < 1 {
+		l.height = 1
+	}
+	return l
+}
+
+// project returns the output pixel position of a scene coordinate
+func (l *layout) project(x, y float32) (float32, float32) {
+	px := (x-l.min.X)*l.opts.Scale + l.opts.Margin
+	py := (y-l.min.Y)*l.opts.Scale + l.opts.Margin
+	if !l.opts.YDown {
+		py = float32(l.height) - py
+	}
+	return px, py
+}
+
+// fixturePoints returns the LEDs of a fixture in address order, coloured
+// along the rainbow exactly like SceneUI.NewRainbowMaterial.
+func (l *layout) fixturePoints(f *fixture.Fixture, first int) []point {
+	tpts := f.Transformed()
+	pts := make([]point, len(tpts))
+	for j, p := range tpts {
+		x, y := l.project(p.X, p.Y)
+		pts[j] = point{X: x, Y: y, Address: first + j,
+			Color: Rainbow(float64(j) / float64(len(tpts)) * 0.67)}
+	}
+	return pts
+}
+
+// strips splits the points of a fixture into polylines, one per strip
+func (l *layout) strips(pts []point) [][]point {
+	if l.opts.StripLength <= 0 {
+		return [][]point{pts}
+	}
+	out := [][]point{}
+	for iX := 0; iX < len(pts); iX += l.opts.StripLength {
+		end := iX + l.opts.StripLength
+		if end > len(pts) {
+			end = len(pts)
+		}
+		out = append(out, pts[iX:end])
+	}
+	return out
+}
+
+// box returns the output rectangle around a fixture
+func (l *layout) box(f *fixture.Fixture) (x0, y0, x1, y1 float32) {
+	x0, y0 = l.project(f.TransformedTopLeft().X, f.TransformedTopLeft().Y)
+	x1, y1 = l.project(f.TransformedBottomRight().X, f.TransformedBottomRight().Y)
+	return math32.Min(x0, x1), math32.Min(y0, y1), math32.Max(x0, x1), math32.Max(y0, y1)
+}
+
+// Rainbow returns the colour at hue 0-1 with full saturation.
+func Rainbow(hue float64) color.RGBA {
+	rgb := hsl.HSL{H: hue, S: 1.0, L: 0.5}.ToRGB()
+	return color.RGBA{uint8(rgb.R * 255), uint8(rgb.G * 255), uint8(rgb.B * 255), 255}
+}
@@ -0,0 +1,58 @@
+package render
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tgreiser/cymapper/cmd/scenebuild/fixture"
+)
+
+func testScene(t *testing.T) *fixture.Scene {
+	path := filepath.Join(t.TempDir(), "strip.tsv")
+	err := os.WriteFile(path, []byte("10\t10\n20\t10\n30\t10\n40\t10\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fixture.NewScene([]*fixture.Fixture{fixture.NewFixture(path)})
+}
+
+func TestSVG(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Width, opts.Height = 100, 50
+	opts.StripLength = 2
+	var buf bytes.Buffer
+	if err := SVG(&buf, testScene(t), opts); err != nil {
+		t.Fatal(err)
+	}
+	svg := buf.String()
+	if n := strings.Count(svg, "<circle"); n != 4 {
+		t.Errorf("Expected 4 LEDs, found %v", n)
+	}
+	if n := strings.Count(svg, `class="strip"`); n != 2 {
+		t.Errorf("Expected 2 strips, found %v", n)
+	}
+	if !strings.Contains(svg, `class="frame"`) {
+		t.Errorf("Scene frame was not drawn")
+	}
+	if !strings.Contains(svg, `fill="#ff0000"`) {
+		t.Errorf("First LED was not red")
+	}
+}
+
+func TestImage(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Width, opts.Height = 100, 50
+	opts.Scale = 2
+	img := Image(testScene(t), opts)
+	if img.Bounds().Dx() != 240 || img.Bounds().Dy() != 140 {
+		t.Fatalf("Image size was incorrect %v", img.Bounds())
+	}
+	// first LED at 10 x 10, y up
+	c := img.RGBAAt(40, 140-40)
+	if c.R != 255 || c.G != 0 || c.B != 0 {
+		t.Errorf("First LED was not red: %v", c)
+	}
+}
@@ -0,0 +1,63 @@
+package render
+
+import (
+	"bufio"
+	"fmt"
+	"image/color"
+	"io"
+	"strings"
+
+	"github.com/tgreiser/cymapper/cmd/scenebuild/fixture"
+)
+
+// SVG draws the scene as an SVG document.
+func SVG(out io.Writer, scene *fixture.Scene, opts Options) error {
+	l := newLayout(scene, opts)
+	w := bufio.NewWriter(out)
+	fmt.Fprintf(w, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n",
+		l.width, l.height, l.width, l.height)
+	fmt.Fprintf(w, `<rect width="100%%" height="100%%" fill="black"/>`+"\n")
+
+	if opts.Width > 0 && opts.Height > 0 {
+		x0, y0 := l.project(0, 0)
+		x1, y1 := l.project(opts.Width, opts.Height)
+		fmt.Fprintf(w, `<polygon class="frame" points="%v,%v %v,%v %v,%v %v,%v" fill="none" stroke="white"/>`+"\n",
+			x0, y0, x0, y1, x1, y1, x1, y0)
+	}
+
+	address := 0
+	for _, f := range scene.Fixtures() {
+		pts := l.fixturePoints(f, address)
+		address += len(pts)
+
+		if opts.Boxes {
+			x0, y0, x1, y1 := l.box(f)
+			fmt.Fprintf(w, `<rect class="fixture" x="%v" y="%v" width="%v" height="%v" fill="none" stroke="red" stroke-dasharray="4"/>`+"\n",
+				x0, y0, x1-x0, y1-y0)
+		}
+		for _, strip := range l.strips(pts) {
+			coords := make([]string, len(strip))
+			for iP, p := range strip {
+				coords[iP] = fmt.Sprintf("%v,%v", p.X, p.Y)
+			}
+			fmt.Fprintf(w, `<polyline class="strip" points="%v" fill="none" stroke="gray"/>`+"\n",
+				strings.Join(coords, " "))
+		}
+		for _, p := range pts {
+			fmt.Fprintf(w, `<circle cx="%v" cy="%v" r="%v" fill="%v"/>`+"\n",
+				p.X, p.Y, opts.PointRadius, hex(p.Color))
+		}
+		for _, p := range pts {
+			if opts.LabelEvery > 0 && p.Address%opts.LabelEvery == 0 {
+				fmt.Fprintf(w, `<text x="%v" y="%v" fill="white" font-family="monospace" font-size="10">%d</text>`+"\n",
+					p.X+opts.PointRadius+1, p.Y-opts.PointRadius-1, p.Address)
+			}
+		}
+	}
+	fmt.Fprintf(w, "</svg>\n")
+	return w.Flush()
+}
+
+func hex(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
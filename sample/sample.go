@@ -0,0 +1,126 @@
+// Package sample reads the colours of video frames at mapped LED positions.
+package sample
+
+import (
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/tgreiser/cymapper/warp"
+)
+
+// Mode is how the colour under an LED is read
+type Mode int
+
+const (
+	Nearest  Mode = iota // The pixel under the LED
+	Bilinear             // Blend of the four nearest pixels
+	Area                 // Average of a box of pixels around the LED
+)
+
+// ParseMode returns the mode named nearest, bilinear or area
+func ParseMode(name string) (Mode, error) {
+	switch name {
+	case "nearest":
+		return Nearest, nil
+	case "bilinear":
+		return Bilinear, nil
+	case "area":
+		return Area, nil
+	}
+	return Nearest, fmt.Errorf("unknown sampling %v, use nearest, bilinear or area", name)
+}
+
+// Options control how frames are sampled
+type Options struct {
+	Mode   Mode
+	Radius float64 // Area: half the size of the averaged box, in frame pixels
+	Width  float64 // Width of the video the map was made for, 0 when it matches the frames
+	Height float64 // Height of the video the map was made for
+}
+
+// Frame fills frame with the colour of img at each point, 3 bytes per LED in
+// address order. The frame is grown to hold every point.
+func Frame(img *image.RGBA, pts []warp.Point, opts Options, frame []byte) []byte {
+	if len(frame) < len(pts)*3 {
+		frame = make([]byte, len(pts)*3)
+	}
+	b := img.Bounds()
+	sx, sy := 1.0, 1.0
+	if opts.Width > 0 && opts.Height > 0 {
+		sx, sy = float64(b.Dx())/opts.Width, float64(b.Dy())/opts.Height
+	}
+	for iP, p := range pts {
+		x, y := float64(b.Min.X)+p.X*sx, float64(b.Min.Y)+p.Y*sy
+		var c [3]float64
+		switch opts.Mode {
+		case Bilinear:
+			c = bilinear(img, x, y)
+		case Area:
+			c = area(img, x, y, opts.Radius*math.Max(sx, sy))
+		default:
+			c = pixel(img, int(math.Round(x)), int(math.Round(y)))
+		}
+		for iC := 0; iC < 3; iC++ {
+			frame[iP*3+iC] = uint8(math.Round(c[iC]))
+		}
+	}
+	return frame
+}
+
+// pixel returns the colour at x, y, clamped to the image
+func pixel(img *image.RGBA, x, y int) [3]float64 {
+	b := img.Bounds()
+	x = clamp(x, b.Min.X, b.Max.X-1)
+	y = clamp(y, b.Min.Y, b.Max.Y-1)
+	i := img.PixOffset(x, y)
+	return [3]float64{float64(img.Pix[i]), float64(img.Pix[i+1]), float64(img.Pix[i+2])}
+}
+
+func clamp(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
+
+func bilinear(img *image.RGBA, x, y float64) [3]float64 {
+	x0, y0 := math.Floor(x), math.Floor(y)
+	fx, fy := x-x0, y-y0
+	ix, iy := int(x0), int(y0)
+	p00, p10 := pixel(img, ix, iy), pixel(img, ix+1, iy)
+	p01, p11 := pixel(img, ix, iy+1), pixel(img, ix+1, iy+1)
+	var c [3]float64
+	for iC := range c {
+		top := p00[iC]*(1-fx) + p10[iC]*fx
+		bottom := p01[iC]*(1-fx) + p11[iC]*fx
+		c[iC] = top*(1-fy) + bottom*fy
+	}
+	return c
+}
+
+func area(img *image.RGBA, x, y, radius float64) [3]float64 {
+	b := img.Bounds()
+	x0 := clamp(int(math.Round(x-radius)), b.Min.X, b.Max.X-1)
+	x1 := clamp(int(math.Round(x+radius)), b.Min.X, b.Max.X-1)
+	y0 := clamp(int(math.Round(y-radius)), b.Min.Y, b.Max.Y-1)
+	y1 := clamp(int(math.Round(y+radius)), b.Min.Y, b.Max.Y-1)
+	var c [3]float64
+	n := 0.0
+	for iy := y0; iy <= y1; iy++ {
+		for ix := x0; ix <= x1; ix++ {
+			p := pixel(img, ix, iy)
+			for iC := range c {
+				c[iC] += p[iC]
+			}
+			n++
+		}
+	}
+	for iC := range c {
+		c[iC] /= n
+	}
+	return c
+}
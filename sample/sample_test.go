@@ -0,0 +1,38 @@
+package sample
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/tgreiser/cymapper/warp"
+)
+
+func TestFrame(t *testing.T) {
+	// black on the left half, white on the right
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	for y := 0; y < 4; y++ {
+		for x := 2; x < 4; x++ {
+			img.Set(x, y, color.RGBA{255, 255, 255, 255})
+		}
+	}
+	pts := []warp.Point{{X: 1.5, Y: 1}, {X: 3, Y: 3}}
+	for _, tc := range []struct {
+		opts Options
+		want []byte
+	}{
+		{Options{Mode: Nearest}, []byte{255, 255, 255, 255, 255, 255}},
+		{Options{Mode: Bilinear}, []byte{128, 128, 128, 255, 255, 255}},
+		{Options{Mode: Area, Radius: 0.5}, []byte{128, 128, 128, 255, 255, 255}},
+		// a map made for an 8 x 8 video lands on the black half
+		{Options{Mode: Nearest, Width: 8, Height: 8}, []byte{0, 0, 0, 255, 255, 255}},
+	} {
+		got := Frame(img, pts, tc.opts, nil)
+		for iX := range tc.want {
+			if got[iX] != tc.want[iX] {
+				t.Errorf("Mode %v sampled %v, expected %v", tc.opts.Mode, got, tc.want)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,82 @@
+// Package capture reads frames through OpenCV, kept apart from package source
+// so the pure Go sources build without it.
+package capture
+
+import (
+	"errors"
+	"image"
+	"io"
+
+	"gocv.io/x/gocv"
+)
+
+// Video reads a video file, an image sequence named like frame%04d.png or a
+// capture device such as a V4L2 loopback
+type Video struct {
+	capture *gocv.VideoCapture
+	mat     gocv.Mat
+	loop    bool
+	fps     float64
+}
+
+// OpenVideo opens path, starting over at the end when loop is set
+func OpenVideo(path string, loop bool) (*Video, error) {
+	capture, err := gocv.VideoCaptureFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return newVideo(capture, loop)
+}
+
+// OpenDevice opens a capture device by ID. A V4L2 loopback fed by OBS or
+// ffmpeg shows up as one of these.
+func OpenDevice(id int) (*Video, error) {
+	capture, err := gocv.VideoCaptureDevice(id)
+	if err != nil {
+		return nil, err
+	}
+	return newVideo(capture, false)
+}
+
+func newVideo(capture *gocv.VideoCapture, loop bool) (*Video, error) {
+	if !capture.IsOpened() {
+		capture.Close()
+		return nil, errors.New("not a video or image sequence")
+	}
+	return &Video{
+		capture: capture,
+		mat:     gocv.NewMat(),
+		loop:    loop,
+		fps:     capture.Get(gocv.VideoCaptureFPS),
+	}, nil
+}
+
+func (v *Video) Read() (*image.RGBA, error) {
+	if ok := v.capture.Read(&v.mat); !ok || v.mat.Empty() {
+		if !v.loop {
+			return nil, io.EOF
+		}
+		v.capture.Set(gocv.VideoCapturePosFrames, 0)
+		if ok := v.capture.Read(&v.mat); !ok || v.mat.Empty() {
+			return nil, io.EOF
+		}
+	}
+	img, err := v.mat.ToImage()
+	if err != nil {
+		return nil, err
+	}
+	rgba, ok := img.(*image.RGBA)
+	if !ok {
+		return nil, errors.New("unsupported frame format")
+	}
+	return rgba, nil
+}
+
+func (v *Video) FPS() float64 {
+	return v.fps
+}
+
+func (v *Video) Close() error {
+	v.mat.Close()
+	return v.capture.Close()
+}
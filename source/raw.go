@@ -0,0 +1,75 @@
+package source
+
+import (
+	"fmt"
+	"image"
+	"io"
+)
+
+// Raw reads packed 8 bit RGB frames of a fixed size, the rgb24 rawvideo
+// that ffmpeg writes with -f rawvideo -pix_fmt rgb24
+type Raw struct {
+	r   io.Reader
+	buf []byte
+	img *image.RGBA
+	fps float64
+}
+
+// NewRaw reads width x height frames from r. fps is the rate the frames are
+// written at, 0 when they are sent as they are rendered.
+func NewRaw(r io.Reader, width, height int, fps float64) *Raw {
+	return &Raw{
+		r:   r,
+		buf: make([]byte, width*height*3),
+		img: image.NewRGBA(image.Rect(0, 0, width, height)),
+		fps: fps,
+	}
+}
+
+func (s *Raw) Read() (*image.RGBA, error) {
+	if _, err := io.ReadFull(s.r, s.buf); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			// the writer stopped part way through a frame
+			return nil, io.EOF
+		}
+		return nil, err
+	}
+	for iP, iB := 0, 0; iB < len(s.buf); iP, iB = iP+4, iB+3 {
+		s.img.Pix[iP] = s.buf[iB]
+		s.img.Pix[iP+1] = s.buf[iB+1]
+		s.img.Pix[iP+2] = s.buf[iB+2]
+		s.img.Pix[iP+3] = 255
+	}
+	return s.img, nil
+}
+
+func (s *Raw) FPS() float64 {
+	return s.fps
+}
+
+func (s *Raw) Close() error {
+	if c, ok := s.r.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// Crop passes on one region of every frame of a source. The region is
+// clipped to the frame, Read fails when none of it is inside.
+type Crop struct {
+	Source
+	Rect image.Rectangle
+}
+
+func (c Crop) Read() (*image.RGBA, error) {
+	img, err := c.Source.Read()
+	if err != nil {
+		return nil, err
+	}
+	rect := c.Rect.Intersect(img.Bounds())
+	if rect.Empty() {
+		b := img.Bounds()
+		return nil, fmt.Errorf("region %v is outside the %v x %v frame", c.Rect, b.Dx(), b.Dy())
+	}
+	return img.SubImage(rect).(*image.RGBA), nil
+}
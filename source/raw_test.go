@@ -0,0 +1,55 @@
+package source
+
+import (
+	"bytes"
+	"image"
+	"io"
+	"testing"
+)
+
+func TestRaw(t *testing.T) {
+	// two 2x1 frames and half of a third
+	data := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+	r := NewRaw(bytes.NewReader(data), 2, 1, 0)
+	img, err := r.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := img.RGBAAt(1, 0); c.R != 4 || c.G != 5 || c.B != 6 || c.A != 255 {
+		t.Errorf("Pixel 1 is %v, expected {4 5 6 255}", c)
+	}
+	if _, err = r.Read(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = r.Read(); err != io.EOF {
+		t.Errorf("Partial frame returned %v, expected EOF", err)
+	}
+}
+
+func TestCrop(t *testing.T) {
+	data := make([]byte, 4*4*3)
+	c := Crop{Source: NewRaw(bytes.NewReader(data), 4, 4, 0), Rect: image.Rect(1, 1, 3, 4)}
+	img, err := c.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b != image.Rect(1, 1, 3, 4) {
+		t.Errorf("Cropped to %v, expected (1,1)-(3,4)", b)
+	}
+}
+
+func TestCropOutside(t *testing.T) {
+	data := make([]byte, 4*4*3*2)
+	c := Crop{Source: NewRaw(bytes.NewReader(data), 4, 4, 0), Rect: image.Rect(2, 3, 10, 10)}
+	img, err := c.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b != image.Rect(2, 3, 4, 4) {
+		t.Errorf("Clipped to %v, expected (2,3)-(4,4)", b)
+	}
+	c.Rect = image.Rect(5, 5, 8, 8)
+	if _, err := c.Read(); err == nil {
+		t.Errorf("Region outside the frame did not fail")
+	}
+}
@@ -0,0 +1,17 @@
+// Package source reads the frames that are played on the LEDs. Video files
+// and capture devices are in package capture.
+package source
+
+import (
+	"image"
+)
+
+// Source is a stream of video frames
+type Source interface {
+	// Read returns the next frame, or io.EOF after the last one. The frame
+	// may be reused by the next Read.
+	Read() (*image.RGBA, error)
+	// FPS is the frame rate of the source, 0 when it is not known
+	FPS() float64
+	Close() error
+}
@@ -0,0 +1,73 @@
+package warp
+
+import (
+	"fmt"
+	"math"
+)
+
+// Anchors give the fraction of the unused space placed before the map on
+// each axis, when it does not fill the video
+var Anchors = map[string][2]float64{
+	"center":       {0.5, 0.5},
+	"top":          {0.5, 0},
+	"bottom":       {0.5, 1},
+	"left":         {0, 0.5},
+	"right":        {1, 0.5},
+	"top-left":     {0, 0},
+	"top-right":    {1, 0},
+	"bottom-left":  {0, 1},
+	"bottom-right": {1, 1},
+}
+
+// Fit places a map, measured in camera pixels from the top left of its
+// frame, inside a video.
+type Fit struct {
+	XScale, YScale float64 // Video pixels per camera pixel
+	Size           Point   // Size of the map in the video
+	Offset         Point   // Top left of the map in the video
+	FlipX, FlipY   bool    // Mirror the map inside its area
+}
+
+// NewFit scales frame into video. Mode is stretch to fill the video,
+// contain to fit inside it, cover to fill it keeping the aspect ratio, or
+// scale for a fixed number of video pixels per camera pixel. Anchor is one
+// of Anchors.
+func NewFit(frame, video Point, mode string, scale float64, anchor string) (Fit, error) {
+	a, ok := Anchors[anchor]
+	if !ok {
+		return Fit{}, fmt.Errorf("unknown anchor: %v", anchor)
+	}
+	if frame.X <= 0 || frame.Y <= 0 {
+		return Fit{}, fmt.Errorf("frame is %v x %v", frame.X, frame.Y)
+	}
+
+	f := Fit{XScale: video.X / frame.X, YScale: video.Y / frame.Y}
+	switch mode {
+	case "stretch":
+	case "contain":
+		f.XScale = math.Min(f.XScale, f.YScale)
+		f.YScale = f.XScale
+	case "cover":
+		f.XScale = math.Max(f.XScale, f.YScale)
+		f.YScale = f.XScale
+	case "scale":
+		f.XScale, f.YScale = scale, scale
+	default:
+		return Fit{}, fmt.Errorf("unknown fit mode: %v", mode)
+	}
+	f.Size = Point{X: frame.X * f.XScale, Y: frame.Y * f.YScale}
+	f.Offset = Point{X: (video.X - f.Size.X) * a[0], Y: (video.Y - f.Size.Y) * a[1]}
+	return f, nil
+}
+
+// Apply returns the position in the video of p, a point in the frame
+func (f Fit) Apply(p Point) Point {
+	x, y := p.X*f.XScale, p.Y*f.YScale
+	if f.FlipX {
+		x = f.Size.X - x
+	}
+	if f.FlipY {
+		y = f.Size.Y - y
+	}
+	return Point{X: x + f.Offset.X, Y: y + f.Offset.Y}
+}
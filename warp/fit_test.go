@@ -0,0 +1,62 @@
+package warp
+
+import "testing"
+
+func TestNewFit(t *testing.T) {
+	video := Point{1280, 720}
+	for _, c := range []struct {
+		frame          Point
+		mode, anchor   string
+		xScale, yScale float64
+		offset         Point
+	}{
+		// a wide frame into 16:9
+		{Point{400, 100}, "stretch", "center", 3.2, 7.2, Point{0, 0}},
+		{Point{400, 100}, "contain", "center", 3.2, 3.2, Point{0, 200}},
+		{Point{400, 100}, "contain", "top", 3.2, 3.2, Point{0, 0}},
+		{Point{400, 100}, "cover", "center", 7.2, 7.2, Point{-800, 0}},
+		// a tall frame
+		{Point{100, 400}, "contain", "left", 1.8, 1.8, Point{0, 0}},
+		{Point{100, 400}, "contain", "bottom-right", 1.8, 1.8, Point{1100, 0}},
+		{Point{100, 400}, "cover", "top", 12.8, 12.8, Point{0, 0}},
+		{Point{100, 400}, "cover", "bottom", 12.8, 12.8, Point{0, -4400}},
+		{Point{100, 400}, "scale", "center", 2, 2, Point{540, -40}},
+	} {
+		f, err := NewFit(c.frame, video, c.mode, 2, c.anchor)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if f.XScale != c.xScale || f.YScale != c.yScale || f.Offset != c.offset {
+			t.Errorf("%v %v at %v scaled %v x %v at %v, expected %v x %v at %v", c.frame, c.mode, c.anchor,
+				f.XScale, f.YScale, f.Offset, c.xScale, c.yScale, c.offset)
+		}
+	}
+
+	if _, err := NewFit(Point{400, 100}, video, "fill", 1, "center"); err == nil {
+		t.Error("Expected an error for an unknown fit mode")
+	}
+	if _, err := NewFit(Point{400, 100}, video, "contain", 1, "middle"); err == nil {
+		t.Error("Expected an error for an unknown anchor")
+	}
+}
+
+func TestFitApply(t *testing.T) {
+	f, err := NewFit(Point{400, 100}, Point{1280, 720}, "contain", 1, "center")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		flipX, flipY bool
+		expected     Point
+	}{
+		{false, false, Point{32, 264}},
+		{true, false, Point{1248, 264}},
+		{false, true, Point{32, 456}},
+		{true, true, Point{1248, 456}},
+	} {
+		f.FlipX, f.FlipY = c.flipX, c.flipY
+		if p := f.Apply(Point{10, 20}); p != c.expected {
+			t.Errorf("Flip %v, %v placed 10, 20 at %v, expected %v", c.flipX, c.flipY, p, c.expected)
+		}
+	}
+}
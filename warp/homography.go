@@ -0,0 +1,170 @@
+// Package warp corrects map coordinates for the camera: perspective
+// (homography), lens distortion and multi-camera triangulation, and fits
+// maps to the video they are played from.
+package warp
+
+import (
+	"errors"
+	"math"
+)
+
+// Homography is a 3x3 projective transform, row major, with H[8] = 1.
+type Homography [9]float64
+
+// Point is a 2D coordinate, in camera pixels or map units.
+type Point struct {
+	X, Y float64
+}
+
+// NewHomography returns the homography that maps each src point onto the
+// dst point with the same index. Four points give an exact solution; more
+// points are fitted by least squares.
+func NewHomography(src, dst []Point) (Homography, error) {
+	if len(src) != len(dst) {
+		return Homography{}, errors.New("homography needs the same number of source and destination points")
+	}
+	if len(src) < 4 {
+		return Homography{}, errors.New("homography needs at least 4 points")
+	}
+
+	// normalise both point sets for a better conditioned system
+	ns, ts := normalize(src)
+	nd, td := normalize(dst)
+
+	// two equations per point in h0..h7, solved with the normal equations
+	ata := make([][]float64, 8)
+	for iX := range ata {
+		ata[iX] = make([]float64, 8)
+	}
+	atb := make([]float64, 8)
+	addRow := func(row []float64, b float64) {
+		for i := 0; i < 8; i++ {
+			for j := 0; j < 8; j++ {
+				ata[i][j] += row[i] * row[j]
+			}
+			atb[i] += row[i] * b
+		}
+	}
+	for iP := range ns {
+		x, y := ns[iP].X, ns[iP].Y
+		u, v := nd[iP].X, nd[iP].Y
+		addRow([]float64{x, y, 1, 0, 0, 0, -x * u, -y * u}, u)
+		addRow([]float64{0, 0, 0, x, y, 1, -x * v, -y * v}, v)
+	}
+	h, err := solve(ata, atb)
+	if err != nil {
+		return Homography{}, errors.New("reference points are collinear or repeated")
+	}
+	hn := Homography{h[0], h[1], h[2], h[3], h[4], h[5], h[6], h[7], 1}
+
+	// undo the normalisation: H = Td^-1 * Hn * Ts
+	tdi, _ := td.Invert()
+	return tdi.Multiply(hn).Multiply(ts).scaled(), nil
+}
+
+// Apply maps a point through the homography.
+func (h Homography) Apply(x, y float64) (float64, float64) {
+	w := h[6]*x + h[7]*y + h[8]
+	return (h[0]*x + h[1]*y + h[2]) / w, (h[3]*x + h[4]*y + h[5]) / w
+}
+
+// Multiply returns h * o, the transform that applies o first and then h.
+func (h Homography) Multiply(o Homography) Homography {
+	var r Homography
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			r[i*3+j] = h[i*3]*o[j] + h[i*3+1]*o[3+j] + h[i*3+2]*o[6+j]
+		}
+	}
+	return r
+}
+
+// Invert returns the inverse transform.
+func (h Homography) Invert() (Homography, error) {
+	det := h[0]*(h[4]*h[8]-h[5]*h[7]) - h[1]*(h[3]*h[8]-h[5]*h[6]) + h[2]*(h[3]*h[7]-h[4]*h[6])
+	if math.Abs(det) < 1e-12 {
+		return Homography{}, errors.New("homography is singular")
+	}
+	r := Homography{
+		h[4]*h[8] - h[5]*h[7], h[2]*h[7] - h[1]*h[8], h[1]*h[5] - h[2]*h[4],
+		h[5]*h[6] - h[3]*h[8], h[0]*h[8] - h[2]*h[6], h[2]*h[3] - h[0]*h[5],
+		h[3]*h[7] - h[4]*h[6], h[1]*h[6] - h[0]*h[7], h[0]*h[4] - h[1]*h[3],
+	}
+	for iX := range r {
+		r[iX] /= det
+	}
+	return r.scaled(), nil
+}
+
+// scaled divides through so that the last element is 1
+func (h Homography) scaled() Homography {
+	if h[8] == 0 {
+		return h
+	}
+	for iX := range h {
+		h[iX] /= h[8]
+	}
+	h[8] = 1
+	return h
+}
+
+// normalize moves the points to their centroid and scales them to an average
+// distance of sqrt(2), returning the moved points and the transform used.
+func normalize(pts []Point) ([]Point, Homography) {
+	var cx, cy float64
+	for _, p := range pts {
+		cx += p.X
+		cy += p.Y
+	}
+	cx /= float64(len(pts))
+	cy /= float64(len(pts))
+	var dist float64
+	for _, p := range pts {
+		dist += math.Hypot(p.X-cx, p.Y-cy)
+	}
+	dist /= float64(len(pts))
+	s := 1.0
+	if dist > 0 {
+		s = math.Sqrt2 / dist
+	}
+	out := make([]Point, len(pts))
+	for iP, p := range pts {
+		out[iP] = Point{(p.X - cx) * s, (p.Y - cy) * s}
+	}
+	return out, Homography{s, 0, -cx * s, 0, s, -cy * s, 0, 0, 1}
+}
+
+// solve solves a * x = b by Gaussian elimination with partial pivoting.
+// a and b are modified.
+func solve(a [][]float64, b []float64) ([]float64, error) {
+	n := len(b)
+	for col := 0; col < n; col++ {
+		pivot := col
+		for row := col + 1; row < n; row++ {
+			if math.Abs(a[row][col]) > math.Abs(a[pivot][col]) {
+				pivot = row
+			}
+		}
+		if math.Abs(a[pivot][col]) < 1e-12 {
+			return nil, errors.New("singular matrix")
+		}
+		a[col], a[pivot] = a[pivot], a[col]
+		b[col], b[pivot] = b[pivot], b[col]
+		for row := col + 1; row < n; row++ {
+			f := a[row][col] / a[col][col]
+			for k := col; k < n; k++ {
+				a[row][k] -= f * a[col][k]
+			}
+			b[row] -= f * b[col]
+		}
+	}
+	x := make([]float64, n)
+	for row := n - 1; row >= 0; row-- {
+		sum := b[row]
+		for k := row + 1; k < n; k++ {
+			sum -= a[row][k] * x[k]
+		}
+		x[row] = sum / a[row][row]
+	}
+	return x, nil
+}
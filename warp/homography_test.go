@@ -0,0 +1,55 @@
+package warp
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNewHomography(t *testing.T) {
+	// keystoned quad, wider at the bottom, onto a 400 x 200 rectangle
+	src := []Point{{110, 100}, {490, 120}, {560, 420}, {40, 400}}
+	dst := []Point{{0, 0}, {400, 0}, {400, 200}, {0, 200}}
+	h, err := NewHomography(src, dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for iP := range src {
+		assertPoint(t, h, src[iP], dst[iP])
+	}
+
+	inv, err := h.Invert()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for iP := range dst {
+		assertPoint(t, inv, dst[iP], src[iP])
+	}
+}
+
+func TestNewHomographyLeastSquares(t *testing.T) {
+	// a pure scale and translate fitted from more than 4 points
+	src := []Point{{0, 0}, {10, 0}, {10, 10}, {0, 10}, {5, 5}, {2, 8}}
+	dst := make([]Point, len(src))
+	for iP, p := range src {
+		dst[iP] = Point{p.X*3 + 7, p.Y*2 - 4}
+	}
+	h, err := NewHomography(src, dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertPoint(t, h, Point{20, 30}, Point{67, 56})
+}
+
+func TestNewHomographyCollinear(t *testing.T) {
+	src := []Point{{0, 0}, {1, 1}, {2, 2}, {3, 3}}
+	if _, err := NewHomography(src, src); err == nil {
+		t.Errorf("Collinear points did not return an error")
+	}
+}
+
+func assertPoint(t *testing.T, h Homography, in, want Point) {
+	x, y := h.Apply(in.X, in.Y)
+	if math.Abs(x-want.X) > 1e-6 || math.Abs(y-want.Y) > 1e-6 {
+		t.Errorf("%v mapped to %v x %v, expected %v", in, x, y, want)
+	}
+}
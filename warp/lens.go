@@ -0,0 +1,73 @@
+package warp
+
+import (
+	"encoding/json"
+	"io/ioutil"
+)
+
+// Lens holds camera intrinsics and Brown-Conrady distortion coefficients in
+// the layout used by OpenCV's calibrateCamera.
+type Lens struct {
+	Width  int     `json:"width"`  // Image width the calibration was made at
+	Height int     `json:"height"` // Image height the calibration was made at
+	FX     float64 `json:"fx"`     // Focal length in pixels
+	FY     float64 `json:"fy"`
+	CX     float64 `json:"cx"` // Principal point
+	CY     float64 `json:"cy"`
+	K1     float64 `json:"k1"` // Radial distortion
+	K2     float64 `json:"k2"`
+	P1     float64 `json:"p1"` // Tangential distortion
+	P2     float64 `json:"p2"`
+	K3     float64 `json:"k3"`
+	Error  float64 `json:"rms_error"`      // RMS reprojection error of the calibration
+	Pose   *Pose   `json:"pose,omitempty"` // Position in the shared world frame, for triangulation
+}
+
+// LoadLens reads a calibration file written by Lens.Save.
+func LoadLens(path string) (*Lens, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	l := new(Lens)
+	err = json.Unmarshal(data, l)
+	return l, err
+}
+
+// Save writes the calibration as JSON.
+func (l *Lens) Save(path string) error {
+	data, err := json.MarshalIndent(l, "", "\t")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+// Distort returns where an ideal pinhole camera pixel is seen through the lens.
+func (l *Lens) Distort(p Point) Point {
+	x := (p.X - l.CX) / l.FX
+	y := (p.Y - l.CY) / l.FY
+	r2 := x*x + y*y
+	radial := 1 + l.K1*r2 + l.K2*r2*r2 + l.K3*r2*r2*r2
+	dx := 2*l.P1*x*y + l.P2*(r2+2*x*x)
+	dy := l.P1*(r2+2*y*y) + 2*l.P2*x*y
+	return Point{(x*radial+dx)*l.FX + l.CX, (y*radial+dy)*l.FY + l.CY}
+}
+
+// Undistort returns the pixel an ideal pinhole camera would have seen, using
+// the same fixed point iteration as OpenCV's undistortPoints. Straight LED
+// strips that bow under barrel distortion come out straight again.
+func (l *Lens) Undistort(p Point) Point {
+	x0 := (p.X - l.CX) / l.FX
+	y0 := (p.Y - l.CY) / l.FY
+	x, y := x0, y0
+	for iX := 0; iX < 20; iX++ {
+		r2 := x*x + y*y
+		icdist := 1 / (1 + l.K1*r2 + l.K2*r2*r2 + l.K3*r2*r2*r2)
+		dx := 2*l.P1*x*y + l.P2*(r2+2*x*x)
+		dy := l.P1*(r2+2*y*y) + 2*l.P2*x*y
+		x = (x0 - dx) * icdist
+		y = (y0 - dy) * icdist
+	}
+	return Point{x*l.FX + l.CX, y*l.FY + l.CY}
+}
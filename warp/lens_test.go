@@ -0,0 +1,18 @@
+package warp
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLensUndistort(t *testing.T) {
+	// wide angle webcam with strong barrel distortion
+	l := &Lens{Width: 1280, Height: 720, FX: 700, FY: 700, CX: 640, CY: 360,
+		K1: -0.3, K2: 0.09, P1: 0.001, P2: -0.0005}
+	for _, p := range []Point{{640, 360}, {100, 80}, {1200, 700}, {900, 200}} {
+		u := l.Undistort(l.Distort(p))
+		if math.Abs(u.X-p.X) > 0.01 || math.Abs(u.Y-p.Y) > 0.01 {
+			t.Errorf("%v round tripped to %v", p, u)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package warp
+
+import (
+	"errors"
+	"math"
+)
+
+// Point3 is a 3D coordinate in the world frame shared by posed cameras.
+type Point3 struct {
+	X, Y, Z float64
+}
+
+// Pose places a camera in the world: a world point P is seen at R*P + T in
+// camera coordinates. R is a row major rotation matrix.
+type Pose struct {
+	R [9]float64 `json:"rotation"`
+	T [3]float64 `json:"translation"`
+}
+
+// Normalize converts an undistorted pixel into pinhole coordinates on the
+// z=1 plane of the camera.
+func (l *Lens) Normalize(p Point) Point {
+	return Point{(p.X - l.CX) / l.FX, (p.Y - l.CY) / l.FY}
+}
+
+// Project returns the pixel where a world point is seen by a posed camera,
+// ignoring lens distortion.
+func (l *Lens) Project(p Point3) Point {
+	r, t := l.Pose.R, l.Pose.T
+	x := r[0]*p.X + r[1]*p.Y + r[2]*p.Z + t[0]
+	y := r[3]*p.X + r[4]*p.Y + r[5]*p.Z + t[1]
+	z := r[6]*p.X + r[7]*p.Y + r[8]*p.Z + t[2]
+	return Point{x/z*l.FX + l.CX, y/z*l.FY + l.CY}
+}
+
+// PoseFromHomography recovers a camera pose from the homography that maps a
+// flat target on the world z=0 plane into pinhole coordinates (see
+// Lens.Normalize), as described by Zhang's calibration method.
+func PoseFromHomography(h Homography) (Pose, error) {
+	h1 := [3]float64{h[0], h[3], h[6]}
+	h2 := [3]float64{h[1], h[4], h[7]}
+	h3 := [3]float64{h[2], h[5], h[8]}
+	n := norm(h1)
+	if n == 0 {
+		return Pose{}, errors.New("degenerate homography")
+	}
+	lambda := 1 / n
+	// keep the target in front of the camera
+	if h3[2]*lambda < 0 {
+		lambda = -lambda
+	}
+	r1 := scale(h1, lambda)
+	r2 := scale(h2, lambda)
+	// make r2 orthogonal to r1 before completing the basis
+	r2 = sub(r2, scale(r1, dot(r1, r2)))
+	r2 = scale(r2, 1/norm(r2))
+	r3 := cross(r1, r2)
+	t := scale(h3, lambda)
+	return Pose{
+		R: [9]float64{
+			r1[0], r2[0], r3[0],
+			r1[1], r2[1], r3[1],
+			r1[2], r2[2], r3[2],
+		},
+		T: t,
+	}, nil
+}
+
+// Triangulate returns the world point that best explains where each posed
+// camera saw it, by linear least squares. pts are undistorted pixels, one
+// per camera, and at least two cameras are needed.
+func Triangulate(cams []*Lens, pts []Point) (Point3, error) {
+	if len(cams) != len(pts) || len(cams) < 2 {
+		return Point3{}, errors.New("triangulation needs one point from each of at least 2 cameras")
+	}
+	ata := [][]float64{make([]float64, 3), make([]float64, 3), make([]float64, 3)}
+	atb := make([]float64, 3)
+	for iC, cam := range cams {
+		if cam.Pose == nil {
+			return Point3{}, errors.New("camera has no pose")
+		}
+		n := cam.Normalize(pts[iC])
+		r, t := cam.Pose.R, cam.Pose.T
+		// x * (row3 . P + t3) = row1 . P + t1, and the same for y with row2
+		for _, eq := range [][2]float64{{n.X, 0}, {n.Y, 3}} {
+			o := int(eq[1])
+			row := []float64{eq[0]*r[6] - r[o], eq[0]*r[7] - r[o+1], eq[0]*r[8] - r[o+2]}
+			b := t[o/3] - eq[0]*t[2]
+			for i := 0; i < 3; i++ {
+				for j := 0; j < 3; j++ {
+					ata[i][j] += row[i] * row[j]
+				}
+				atb[i] += row[i] * b
+			}
+		}
+	}
+	x, err := solve(ata, atb)
+	if err != nil {
+		return Point3{}, errors.New("cameras are looking along the same ray")
+	}
+	return Point3{x[0], x[1], x[2]}, nil
+}
+
+func dot(a, b [3]float64) float64 {
+	return a[0]*b[0] + a[1]*b[1] + a[2]*b[2]
+}
+
+func norm(a [3]float64) float64 {
+	return math.Sqrt(dot(a, a))
+}
+
+func scale(a [3]float64, s float64) [3]float64 {
+	return [3]float64{a[0] * s, a[1] * s, a[2] * s}
+}
+
+func sub(a, b [3]float64) [3]float64 {
+	return [3]float64{a[0] - b[0], a[1] - b[1], a[2] - b[2]}
+}
+
+func cross(a, b [3]float64) [3]float64 {
+	return [3]float64{
+		a[1]*b[2] - a[2]*b[1],
+		a[2]*b[0] - a[0]*b[2],
+		a[0]*b[1] - a[1]*b[0],
+	}
+}
@@ -0,0 +1,62 @@
+package warp
+
+import (
+	"math"
+	"testing"
+)
+
+// two cameras 1m apart, the second turned 30 degrees towards the first
+func testCameras() []*Lens {
+	c, s := math.Cos(math.Pi/6), math.Sin(math.Pi/6)
+	return []*Lens{
+		{FX: 800, FY: 800, CX: 640, CY: 360, Pose: &Pose{
+			R: [9]float64{1, 0, 0, 0, 1, 0, 0, 0, 1},
+			T: [3]float64{0, 0, 2},
+		}},
+		{FX: 800, FY: 800, CX: 640, CY: 360, Pose: &Pose{
+			R: [9]float64{c, 0, -s, 0, 1, 0, s, 0, c},
+			T: [3]float64{-1, 0, 2.2},
+		}},
+	}
+}
+
+func TestTriangulate(t *testing.T) {
+	cams := testCameras()
+	for _, want := range []Point3{{0, 0, 0}, {0.3, -0.2, 0.5}, {-0.4, 0.1, -0.3}} {
+		got, err := Triangulate(cams, []Point{cams[0].Project(want), cams[1].Project(want)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(got.X-want.X) > 1e-6 || math.Abs(got.Y-want.Y) > 1e-6 || math.Abs(got.Z-want.Z) > 1e-6 {
+			t.Errorf("Triangulated %v, expected %v", got, want)
+		}
+	}
+}
+
+func TestPoseFromHomography(t *testing.T) {
+	cam := testCameras()[1]
+	// a flat target on the z=0 plane, seen in pinhole coordinates
+	src := []Point{{0, 0}, {0.5, 0}, {0.5, 0.3}, {0, 0.3}, {0.2, 0.1}}
+	dst := make([]Point, len(src))
+	for iP, p := range src {
+		dst[iP] = cam.Normalize(cam.Project(Point3{p.X, p.Y, 0}))
+	}
+	h, err := NewHomography(src, dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pose, err := PoseFromHomography(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for iX := range pose.R {
+		if math.Abs(pose.R[iX]-cam.Pose.R[iX]) > 1e-6 {
+			t.Fatalf("Rotation was incorrect %v did not match %v", pose.R, cam.Pose.R)
+		}
+	}
+	for iX := range pose.T {
+		if math.Abs(pose.T[iX]-cam.Pose.T[iX]) > 1e-6 {
+			t.Fatalf("Translation was incorrect %v did not match %v", pose.T, cam.Pose.T)
+		}
+	}
+}
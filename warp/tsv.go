@@ -0,0 +1,49 @@
+package warp
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// ReadPoints reads a map TSV, one x and y per line in address order.
+func ReadPoints(r io.Reader) ([]Point, error) {
+	reader := csv.NewReader(r)
+	reader.Comma = '\t'
+	reader.FieldsPerRecord = -1
+	lines, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	pts := make([]Point, len(lines))
+	for iL, line := range lines {
+		if len(line) < 2 {
+			return nil, fmt.Errorf("line %v: expected x and y", iL+1)
+		}
+		if pts[iL].X, err = strconv.ParseFloat(line[0], 64); err != nil {
+			return nil, fmt.Errorf("line %v: %v", iL+1, err)
+		}
+		if pts[iL].Y, err = strconv.ParseFloat(line[1], 64); err != nil {
+			return nil, fmt.Errorf("line %v: %v", iL+1, err)
+		}
+	}
+	return pts, nil
+}
+
+// WritePoints writes pts as a map TSV.
+func WritePoints(w io.Writer, pts []Point) error {
+	out := csv.NewWriter(w)
+	out.Comma = '\t'
+	for _, p := range pts {
+		err := out.Write([]string{
+			strconv.FormatFloat(p.X, 'f', -1, 32),
+			strconv.FormatFloat(p.Y, 'f', -1, 32),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	out.Flush()
+	return out.Error()
+}